@@ -0,0 +1,105 @@
+package deepcopy
+
+import (
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// Allocator 为拷贝出的指针目标分配内存。
+// New 返回指向类型 t 零值的 *t，语义与 reflect.New 相同。
+// 每次 Copy/Clone 通过 SetAllocator 注册的工厂取得一个新的 Allocator，
+// 因此实现无需考虑跨拷贝的复用；并行模式下调用会被串行化。
+type Allocator interface {
+	New(t reflect.Type) reflect.Value
+}
+
+// SetAllocator 设置分配器工厂，nil 恢复默认的 reflect.New
+func (c *Copier) SetAllocator(factory func() Allocator) *Copier {
+	c.alloc = factory
+	return c
+}
+
+// newElem 分配指针目标：未设置分配器时直接 reflect.New
+func (st *copyState) newElem(tc *typeCopier) reflect.Value {
+	if st.alloc == nil {
+		return reflect.New(tc.typ.Elem())
+	}
+	return st.alloc.New(tc.typ.Elem())
+}
+
+// lockedAllocator 并行拷贝时包装用户分配器，使其无需自行处理并发
+type lockedAllocator struct {
+	mu sync.Mutex
+	a  Allocator
+}
+
+func (l *lockedAllocator) New(t reflect.Type) reflect.Value {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.a.New(t)
+}
+
+const (
+	slabMinLen   = 8
+	slabMaxBytes = 256 << 10 // 单块上限，避免为少量对象预留过多内存
+)
+
+// slab 同一类型的一块连续内存，按元素顺序切出
+type slab struct {
+	typ  reflect.Type
+	base unsafe.Pointer
+	size uintptr
+	next int
+	n    int
+}
+
+// SlabAllocator 按类型成块分配：同类型的对象从同一个 []T 中依次切出，
+// 块用尽后按几何级数扩大下一块。拷贝 100 万个 *Node 只需几十次堆分配。
+//
+// 代价：块内任一对象存活都会让整块内存存活，适合整张图一起使用、一起丢弃的场景。
+type SlabAllocator struct {
+	last  *slab // 连续分配同一类型时跳过 map 查找
+	slabs map[reflect.Type]*slab
+}
+
+// NewSlabAllocator 返回一个空的 SlabAllocator，可直接作为 SetAllocator 的工厂：
+//
+//	c.SetAllocator(deepcopy.NewSlabAllocator)
+func NewSlabAllocator() Allocator {
+	return &SlabAllocator{slabs: make(map[reflect.Type]*slab)}
+}
+
+func (a *SlabAllocator) New(t reflect.Type) reflect.Value {
+	s := a.last
+	if s == nil || s.typ != t {
+		s = a.slabs[t]
+		if s == nil {
+			if t.Size() == 0 {
+				return reflect.New(t)
+			}
+			s = &slab{typ: t, size: t.Size()}
+			a.slabs[t] = s
+		}
+		a.last = s
+	}
+
+	if s.next == s.n {
+		s.refill()
+	}
+	p := unsafe.Add(s.base, uintptr(s.next)*s.size)
+	s.next++
+	return reflect.NewAt(t, p)
+}
+
+// refill 分配下一块，长度翻倍直到达到 slabMaxBytes
+func (s *slab) refill() {
+	n := max(s.n*2, slabMinLen)
+	if limit := int(slabMaxBytes / s.size); n > limit {
+		n = max(limit, 1)
+	}
+	buf := reflect.MakeSlice(reflect.SliceOf(s.typ), n, n)
+	s.base = buf.UnsafePointer()
+	s.n = n
+	s.next = 0
+}
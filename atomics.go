@@ -0,0 +1,129 @@
+package deepcopy
+
+import (
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"unsafe"
+)
+
+// atomicOp sync/atomic 类型的拷贝方式，非 atomic 类型为 atomicNone
+type atomicOp uint8
+
+const (
+	atomicNone atomicOp = iota
+	atomicInt32
+	atomicInt64
+	atomicUint32
+	atomicUint64
+	atomicUintptr
+	atomicBool
+	atomicPointer // atomic.Pointer[T]：载入的目标按 *T 的计划深拷贝
+	atomicValue   // atomic.Value：载入的值按 any 的计划深拷贝
+)
+
+var atomicTypes = map[reflect.Type]atomicOp{
+	reflect.TypeFor[atomic.Int32]():   atomicInt32,
+	reflect.TypeFor[atomic.Int64]():   atomicInt64,
+	reflect.TypeFor[atomic.Uint32]():  atomicUint32,
+	reflect.TypeFor[atomic.Uint64]():  atomicUint64,
+	reflect.TypeFor[atomic.Uintptr](): atomicUintptr,
+	reflect.TypeFor[atomic.Bool]():    atomicBool,
+	reflect.TypeFor[atomic.Value]():   atomicValue,
+}
+
+// atomicOpOf 识别 sync/atomic 的类型化值。atomic.Pointer[T] 为泛型，按包名与类型名前缀识别，
+// 其唯一的非零宽字段 v 位于偏移 0，以 atomic.LoadPointer/StorePointer 访问
+func atomicOpOf(t reflect.Type) atomicOp {
+	if op, ok := atomicTypes[t]; ok {
+		return op
+	}
+	if t.Kind() == reflect.Struct && t.PkgPath() == "sync/atomic" && strings.HasPrefix(t.Name(), "Pointer[") {
+		if f, ok := t.FieldByName("v"); ok && f.Offset == 0 && f.Type.Kind() == reflect.UnsafePointer {
+			return atomicPointer
+		}
+	}
+	return atomicNone
+}
+
+// atomicPointerTarget atomic.Pointer[T] 的 *T 类型（取自其 _ [0]*T 字段）
+func atomicPointerTarget(t reflect.Type) reflect.Type {
+	return t.Field(0).Type.Elem()
+}
+
+// srcAddr 原子读取需要 src 的地址，不可寻址时先落到临时变量上（此时不存在并发写）
+func srcAddr(src reflect.Value) unsafe.Pointer {
+	if !src.CanAddr() {
+		src = addressable(src)
+	}
+	return unsafe.Pointer(src.UnsafeAddr())
+}
+
+func (tc *typeCopier) copyAtomic(src reflect.Value, st *copyState) reflect.Value {
+	dst := reflect.New(tc.typ)
+	tc.storeAtomic(dst.UnsafePointer(), srcAddr(src), false, st)
+	return dst.Elem()
+}
+
+func (tc *typeCopier) copyAtomicInto(dst, src reflect.Value, st *copyState) {
+	tc.storeAtomic(unsafe.Pointer(dst.UnsafeAddr()), srcAddr(src), true, st)
+}
+
+// storeAtomic 从 s 原子载入、写入 d。into 为 true 时 d 是已有对象：
+// atomic.Pointer 复用其当前目标，atomic.Value 在类型变化时先重置
+func (tc *typeCopier) storeAtomic(d, s unsafe.Pointer, into bool, st *copyState) {
+	switch tc.aop {
+	case atomicInt32:
+		(*atomic.Int32)(d).Store((*atomic.Int32)(s).Load())
+	case atomicInt64:
+		(*atomic.Int64)(d).Store((*atomic.Int64)(s).Load())
+	case atomicUint32:
+		(*atomic.Uint32)(d).Store((*atomic.Uint32)(s).Load())
+	case atomicUint64:
+		(*atomic.Uint64)(d).Store((*atomic.Uint64)(s).Load())
+	case atomicUintptr:
+		(*atomic.Uintptr)(d).Store((*atomic.Uintptr)(s).Load())
+	case atomicBool:
+		(*atomic.Bool)(d).Store((*atomic.Bool)(s).Load())
+	case atomicPointer:
+		target := tc.elem.typ.Elem()
+		p := reflect.NewAt(target, atomic.LoadPointer((*unsafe.Pointer)(s)))
+		var copied reflect.Value
+		if into {
+			cur := reflect.NewAt(target, atomic.LoadPointer((*unsafe.Pointer)(d)))
+			copied = tc.elem.ptrInto(cur, p, st)
+		} else {
+			copied = tc.elem.copy(p, st)
+		}
+		atomic.StorePointer((*unsafe.Pointer)(d), copied.UnsafePointer())
+	case atomicValue:
+		tc.storeAtomicValue((*atomic.Value)(d), (*atomic.Value)(s).Load(), into, st)
+	}
+}
+
+func (tc *typeCopier) storeAtomicValue(d *atomic.Value, v any, into bool, st *copyState) {
+	var cur any
+	if into {
+		cur = d.Load()
+	}
+	if v == nil {
+		if cur != nil {
+			reflect.ValueOf(d).Elem().SetZero() // Value 不接受 Store(nil)，只能整体重置
+		}
+		return
+	}
+
+	var copied any
+	if cur != nil && reflect.TypeOf(cur) == reflect.TypeOf(v) {
+		cell := reflect.New(anyType).Elem()
+		cell.Set(reflect.ValueOf(&cur).Elem())
+		tc.elem.copyInto(cell, reflect.ValueOf(&v).Elem(), st)
+		copied = cell.Interface()
+	} else {
+		if cur != nil {
+			reflect.ValueOf(d).Elem().SetZero() // 动态类型变化时 Store 会 panic，先重置
+		}
+		copied = tc.copyAny(v, st)
+	}
+	d.Store(copied)
+}
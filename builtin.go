@@ -0,0 +1,146 @@
+package deepcopy
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"strings"
+	"unsafe"
+)
+
+// builtinPlan 标准库类型的专用拷贝方式：通过其公开 API（必要时按核对过的内部布局）
+// 重建一个合法、独立的值，而不是照搬未导出状态。不受 copyUnexported 影响。
+type builtinPlan struct {
+	// fill 把 src 的内容写入 dst：into 为 false 时 dst 指向新分配的零值，
+	// 为 true 时指向 CopyInto/Restore 的已有对象
+	fill func(tc *typeCopier, dst, src unsafe.Pointer, into bool, st *copyState)
+	// elem 内部值需要的计划类型（解析到 tc.elem），nil 表示不需要
+	elem reflect.Type
+	// identity 依赖身份表（如 weak.Pointer 查找目标的拷贝），即使没有 elem 也需要开启跟踪
+	identity bool
+	// inPlace 值内含指向自身的指针（list.List 的哨兵元素），只能直接建在最终地址上
+	inPlace bool
+	// weak weak.Pointer：拷贝结束时才可能回填，所在的值不能在此之前被整体搬运
+	weak bool
+}
+
+var builtinPlans = map[reflect.Type]*builtinPlan{
+	reflect.TypeFor[bytes.Buffer](): {fill: fillBuffer},
+	reflect.TypeFor[big.Int]():      {fill: fillBigInt},
+	reflect.TypeFor[big.Rat]():      {fill: fillBigRat},
+	reflect.TypeFor[big.Float]():    {fill: fillBigFloat},
+}
+
+func init() {
+	// strings.Builder 只能按内部布局处理，布局与预期不一致时退回普通结构体计划
+	if layoutMatches(reflect.TypeFor[strings.Builder](), reflect.TypeFor[builderLayout]()) {
+		builtinPlans[reflect.TypeFor[strings.Builder]()] = &builtinPlan{fill: fillBuilder}
+	}
+}
+
+// builtinPlanOf 查找 t 的专用计划：先查固定类型，再按泛型实例的名称匹配
+func builtinPlanOf(t reflect.Type) *builtinPlan {
+	if bp := builtinPlans[t]; bp != nil {
+		return bp
+	}
+	return weakPlanOf(t)
+}
+
+// layoutMatches 逐字段核对镜像结构体与真实类型的名称、偏移和大小
+func layoutMatches(t, mirror reflect.Type) bool {
+	if t.Size() != mirror.Size() || t.NumField() != mirror.NumField() {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f, m := t.Field(i), mirror.Field(i)
+		if f.Name != m.Name || f.Offset != m.Offset || f.Type.Size() != m.Type.Size() {
+			return false
+		}
+	}
+	return true
+}
+
+func (tc *typeCopier) copyBuiltin(src reflect.Value, st *copyState) reflect.Value {
+	dst := reflect.New(tc.typ)
+	tc.bp.fill(tc, dst.UnsafePointer(), srcAddr(src), false, st)
+	return dst.Elem()
+}
+
+func (tc *typeCopier) copyBuiltinInto(dst, src reflect.Value, st *copyState) {
+	tc.bp.fill(tc, unsafe.Pointer(dst.UnsafeAddr()), srcAddr(src), true, st)
+}
+
+// builderLayout strings.Builder 的内部布局镜像
+type builderLayout struct {
+	addr unsafe.Pointer // 指向自身，用于检测按值拷贝
+	buf  []byte
+}
+
+// fillBuilder 复制内容到新的底层数组并清空自指针：Builder 的零 addr 合法，
+// 下一次写入时会绑定到它最终所在的地址，因此拷贝结果无论被搬到哪里都能继续写。
+// 即使是 CopyInto 也不复用 dst 原有的 buf——String() 返回的字符串与之共享内存。
+func fillBuilder(_ *typeCopier, d, s unsafe.Pointer, _ bool, _ *copyState) {
+	src, dst := (*builderLayout)(s), (*builderLayout)(d)
+	dst.addr = nil
+	if src.buf == nil {
+		dst.buf = nil
+		return
+	}
+	dst.buf = append(make([]byte, 0, cap(src.buf)), src.buf...)
+}
+
+// fillBuffer 只保留未读部分：off 与 lastRead 归零，得到与 src 读出内容相同的新 Buffer
+func fillBuffer(_ *typeCopier, d, s unsafe.Pointer, into bool, _ *copyState) {
+	src, dst := (*bytes.Buffer)(s), (*bytes.Buffer)(d)
+	if into {
+		dst.Reset() // 保留已有容量
+	}
+	if src.Len() > 0 {
+		dst.Write(src.Bytes())
+	}
+}
+
+// math/big 的值存放在未导出的切片里，经 Set 复制得到独立的底层数组；
+// CopyInto 时 Set 复用 dst 已有的容量
+func fillBigInt(_ *typeCopier, d, s unsafe.Pointer, _ bool, _ *copyState) {
+	(*big.Int)(d).Set((*big.Int)(s))
+}
+
+func fillBigRat(_ *typeCopier, d, s unsafe.Pointer, _ bool, _ *copyState) {
+	(*big.Rat)(d).Set((*big.Rat)(s))
+}
+
+// fillBigFloat 用 Copy 而非 Set：Set 会按 dst 已有的精度舍入，Copy 连同精度、
+// 舍入模式和 Accuracy 原样复制
+func fillBigFloat(_ *typeCopier, d, s unsafe.Pointer, _ bool, _ *copyState) {
+	(*big.Float)(d).Copy((*big.Float)(s))
+}
+
+// analyzeInPlace 标记按值含有 inPlace 专用计划的结构体与数组。
+// 指针、切片、map 的目标本就单独分配在堆上，不向外传递
+func analyzeInPlace(order []*typeCopier) {
+	for _, tc := range order {
+		tc.inPlace = tc.kind == kindBuiltin && tc.bp.inPlace
+	}
+	propagateByValue(order, func(tc *typeCopier) *bool { return &tc.inPlace })
+}
+
+// propagateByValue 把 flag 从数组元素、结构体字段传给所在的数组与结构体，直到不再变化
+func propagateByValue(order []*typeCopier, flag func(*typeCopier) *bool) {
+	for changed := true; changed; {
+		changed = false
+		for _, tc := range order {
+			f := flag(tc)
+			if *f {
+				continue
+			}
+			switch {
+			case tc.kind == kindArray && *flag(tc.elem):
+				*f = true
+			case tc.kind == kindStruct && tc.anyChild(func(child *typeCopier) bool { return *flag(child) }):
+				*f = true
+			}
+			changed = changed || *f
+		}
+	}
+}
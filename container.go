@@ -0,0 +1,194 @@
+package deepcopy
+
+import (
+	"container/list"
+	"container/ring"
+	"reflect"
+	"runtime"
+	"unsafe"
+)
+
+var (
+	listType        = reflect.TypeFor[list.List]()
+	listPtrType     = reflect.TypeFor[*list.List]()
+	listPtrRT       = rtypeOf(listPtrType)
+	listElemPtrType = reflect.TypeFor[*list.Element]()
+	listElemPtrRT   = rtypeOf(listElemPtrType)
+)
+
+func init() {
+	// 元素的 list 指针与哨兵节点只能按内部布局改写，布局与预期不一致时退回普通计划
+	if layoutMatches(listType, reflect.TypeFor[listLayout]()) &&
+		layoutMatches(reflect.TypeFor[list.Element](), reflect.TypeFor[elementLayout]()) {
+		builtinPlans[listType] = &builtinPlan{fill: fillList, elem: anyType, inPlace: true}
+		builtinPlans[listElemPtrType] = &builtinPlan{fill: fillListElement, elem: listPtrType}
+	}
+	if layoutMatches(reflect.TypeFor[ring.Ring](), reflect.TypeFor[ringLayout]()) {
+		builtinPlans[reflect.TypeFor[*ring.Ring]()] = &builtinPlan{fill: fillRing, elem: anyType}
+	}
+}
+
+// elementLayout list.Element 的内部布局镜像
+type elementLayout struct {
+	next, prev unsafe.Pointer
+	list       unsafe.Pointer
+	Value      any
+}
+
+// listLayout list.List 的内部布局镜像
+type listLayout struct {
+	root elementLayout // 哨兵节点，空链表时 next/prev 指向自身
+	len  int
+}
+
+// ringLayout ring.Ring 的内部布局镜像
+type ringLayout struct {
+	next, prev unsafe.Pointer // 零值 Ring 为 nil，首次使用时才链接到自身
+	Value      any
+}
+
+// fillList 在 d 处重建 src 的链表。哨兵节点含指向自身的指针，d 必须是最终地址
+// （copyAt 保证这一点）。元素先全部创建并登记，再逐个拷贝 Value，
+// Value 里指回本链表的 *list.Element 因此总能映射到新链表的元素。
+func fillList(tc *typeCopier, d, s unsafe.Pointer, _ bool, st *copyState) {
+	src, dst := (*list.List)(s), (*list.List)(d)
+	t := st.visited
+	if t != nil {
+		// 按 *list.List 登记：按值嵌入的 List 也能被指向它的指针和元素找到
+		t.lock()
+		p, ok := t.lookup(uintptr(s), listPtrRT)
+		if !ok {
+			t.insert(uintptr(s), listPtrRT, d)
+		} else if p != d {
+			// 元素指针先于链表字段被拷贝，链表已经建在别处：整体迁到 d
+			moveList(dst, (*list.List)(p))
+			t.replace(uintptr(s), listPtrRT, d)
+		}
+		t.unlock()
+		if ok && p != d {
+			return
+		}
+	}
+
+	dst.Init()
+	if src.Len() == 0 {
+		return
+	}
+	if t == nil {
+		for e := src.Front(); e != nil; e = e.Next() {
+			dst.PushBack(nil)
+		}
+	} else {
+		t.lock()
+		for e := src.Front(); e != nil; e = e.Next() {
+			t.insert(uintptr(unsafe.Pointer(e)), listElemPtrRT, unsafe.Pointer(dst.PushBack(nil)))
+		}
+		t.unlock()
+	}
+	for e, ne := src.Front(), dst.Front(); e != nil; e, ne = e.Next(), ne.Next() {
+		ne.Value = tc.copyAny(e.Value, st)
+	}
+}
+
+// moveList 把 from 的全部元素原样迁到 to，元素身份不变，from 变为空链表
+func moveList(to, from *list.List) {
+	tl, fl := (*listLayout)(unsafe.Pointer(to)), (*listLayout)(unsafe.Pointer(from))
+	if fl.len == 0 {
+		to.Init()
+		return
+	}
+	root := unsafe.Pointer(&tl.root)
+	tl.root.next, tl.root.prev = fl.root.next, fl.root.prev
+	(*elementLayout)(tl.root.next).prev = root
+	(*elementLayout)(tl.root.prev).next = root
+	for p := tl.root.next; p != root; p = (*elementLayout)(p).next {
+		(*elementLayout)(p).list = unsafe.Pointer(to)
+	}
+	tl.len = fl.len
+	*fl = listLayout{}
+}
+
+// fillListElement 把 *list.Element 映射到所属链表拷贝中的对应元素，
+// 链表尚未拷贝时先经 *list.List 的计划（tc.elem）拷贝它。
+// 已移出链表的元素，或关闭身份跟踪时，只拷贝元素本身和它的 Value。
+func fillListElement(tc *typeCopier, d, s unsafe.Pointer, _ bool, st *copyState) {
+	src, dst := *(**list.Element)(s), (**list.Element)(d)
+	if src == nil {
+		*dst = nil
+		return
+	}
+	t := st.visited
+	key := uintptr(unsafe.Pointer(src))
+	if t != nil {
+		if owner := (*elementLayout)(unsafe.Pointer(src)).list; owner != nil {
+			tc.elem.copy(reflect.NewAt(listType, owner), st)
+		}
+		for {
+			t.lock()
+			p, ok := t.lookup(key, listElemPtrRT)
+			if !ok && (*elementLayout)(unsafe.Pointer(src)).list == nil {
+				// 游离元素：登记一个新元素，其它指向它的指针共享同一份拷贝
+				p = unsafe.Pointer(&list.Element{})
+				t.insert(key, listElemPtrRT, p)
+				t.unlock()
+				*dst = (*list.Element)(p)
+				(*dst).Value = tc.elem.elem.copyAny(src.Value, st)
+				return
+			}
+			t.unlock()
+			if ok {
+				*dst = (*list.Element)(p)
+				return
+			}
+			if !t.shared {
+				break
+			}
+			// 并行时链表已被另一个 worker 认领，元素正在登记
+			runtime.Gosched()
+		}
+	}
+	*dst = &list.Element{Value: tc.elem.elem.copyAny(src.Value, st)}
+}
+
+// fillRing 按 Next 顺序重建整个环。所有节点先登记再拷贝 Value，
+// 从环上任一节点进入都得到同一个环的拷贝
+func fillRing(tc *typeCopier, d, s unsafe.Pointer, _ bool, st *copyState) {
+	src, dst := *(**ring.Ring)(s), (**ring.Ring)(d)
+	if src == nil {
+		*dst = nil
+		return
+	}
+	t := st.visited
+	if t != nil {
+		t.lock()
+		if p, ok := t.lookup(uintptr(unsafe.Pointer(src)), tc.rtype); ok {
+			t.unlock()
+			*dst = (*ring.Ring)(p)
+			return
+		}
+	}
+
+	// 零值 Ring 尚未链接，调用 Len/Next 会写 src，按单节点环处理
+	n := 1
+	if (*ringLayout)(unsafe.Pointer(src)).next != nil {
+		n = src.Len()
+	}
+	r := ring.New(n)
+	nodes := make([]*ring.Ring, 0, 2*n) // src 与 dst 的节点交替存放
+	for p, q := src, r; ; p, q = p.Next(), q.Next() {
+		nodes = append(nodes, p, q)
+		if len(nodes) == 2*n {
+			break
+		}
+	}
+	if t != nil {
+		for i := 0; i < len(nodes); i += 2 {
+			t.insert(uintptr(unsafe.Pointer(nodes[i])), tc.rtype, unsafe.Pointer(nodes[i+1]))
+		}
+		t.unlock()
+	}
+	for i := 0; i < len(nodes); i += 2 {
+		nodes[i+1].Value = tc.copyAny(nodes[i].Value, st)
+	}
+	*dst = r
+}
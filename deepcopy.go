@@ -2,6 +2,7 @@ package deepcopy
 
 import (
 	"fmt"
+	_ "maps" // mapsClone 的 linkname 目标
 	"reflect"
 	"sync"
 	"sync/atomic"
@@ -13,11 +14,10 @@ import (
 //go:linkname runtimeMemmove runtime.memmove
 func runtimeMemmove(to, from unsafe.Pointer, n uintptr)
 
-// visitKey 用于循环引用检测（仅 Ptr/Map/Chan）
-type visitKey struct {
-	ptr uintptr
-	typ reflect.Type
-}
+// mapsClone 即 maps.Clone 的运行时实现，可对任意 map 类型整表克隆
+//
+//go:linkname mapsClone maps.clone
+func mapsClone(m any) any
 
 // copierKind 使用 uint8 压缩内存
 type copierKind uint8
@@ -31,25 +31,66 @@ const (
 	kindStruct
 	kindInterface
 	kindUnsupported
+	kindShare   // 身份敏感类型：按引用共享，不深拷贝
+	kindSyncMap // sync.Map：经 Range/Store 深拷贝
+	kindAtomic  // sync/atomic 类型化值：经 Load/Store 拷贝，aop 决定具体方式
+	kindBuiltin // 标准库专用计划（bytes.Buffer、strings.Builder 等），见 bp
+	kindZero    // Zero 规则：结果为零值
+	kindSkip    // Skip 规则：不拷贝，CopyInto 保留 dst 原值
 )
 
 // typeCopier 压缩布局，64位系统下从 72 字节降至 48 字节
 // 顺序：大字段在前，小字段在后，减少 padding
 type typeCopier struct {
 	// 8 字节对齐字段
-	typ  reflect.Type
-	elem *typeCopier // Slice/Array/Ptr 的元素
-	key  *typeCopier // Map 的 key
+	typ   reflect.Type
+	rtype unsafe.Pointer // typ 的 *rtype，身份表用它作为类型 key
+	elem  *typeCopier    // Slice/Array/Ptr 的元素
+	key   *typeCopier    // Map 的 key
+
+	// 接口专用：最近见过的动态类型 -> 计划的内联缓存
+	ic atomic.Pointer[ifaceCache]
 
 	// 结构体专用，nil 表示非结构体（节省 8 字节 nil 指针）
 	fields *[]fieldCopier // 使用指针指向切片，减少空结构体的内存浪费
 
+	// 严格模式下编译期发现的错误（含子计划传播上来的），nil 表示可拷贝
+	err error
+
+	// kindBuiltin 专用：标准库类型的拷贝方式
+	bp *builtinPlan
+
 	// 4 字节字段
 	arrayLen int32 // Array 长度，int32 足够（最大 2^31-1）
 
 	// 1 字节字段
-	kind  copierKind
+	kind copierKind
+	// isPOD：Basic/Array/Struct 表示整个值不含指针且按选项无需跳过任何字段，
+	// 可整块 memmove；Slice 表示元素满足上述条件；Map 表示 key 和 value 都是 flat
 	isPOD bool
+	// flat：值的浅拷贝即深拷贝（POD、string 及其数组/结构体组合）
+	flat bool
+	// hasRefs：类型图中可达指针、map 或接口
+	hasRefs bool
+	// needsVisit：同一次拷贝中可能两次遇到同一引用（环或共享），需要 visited 跟踪
+	needsVisit bool
+	// json：map[string]any 或 []any，走无反射的 JSON 树克隆
+	json bool
+	// needsAddr：结构体含要拷贝的未导出字段，只能按偏移读取，src 须可寻址
+	needsAddr bool
+	// aop：kindAtomic 的具体类型
+	aop atomicOp
+	// lk：结构体在锁感知模式下的加锁方式
+	lk lockKind
+	// hk：结构体实现的拷贝钩子
+	hk hookSet
+	// hooked：拷贝中可能执行钩子（自身、子计划或接口的动态类型），需要记录字段路径
+	hooked bool
+	// inPlace：值本身（含按值嵌入的字段、数组元素）带自指针，见 builtinPlan.inPlace
+	inPlace bool
+	// holdsWeak：值本身（含按值嵌入的字段、数组元素）可能带待回填的弱指针，
+	// 作为 map 的 key/value 时需要在回填后重新写入，见 analyzeWeak
+	holdsWeak bool
 }
 
 // fieldCopier 结构体字段描述符，32 字节（紧凑布局）
@@ -62,63 +103,45 @@ type fieldCopier struct {
 	_         [3]byte      // padding 到 32 字节
 }
 
-// visitedPool 复用 visited map，减少 GC 压力
-var visitedPool = sync.Pool{
-	New: func() interface{} {
-		return make(map[visitKey]reflect.Value, 16) // 预分配初始容量
-	},
-}
-
-func acquireVisited() map[visitKey]reflect.Value {
-	m := visitedPool.Get().(map[visitKey]reflect.Value)
-	clear(m) // Go 1.21+，快速清空 map 保留容量
-	return m
-}
-
-func releaseVisited(m map[visitKey]reflect.Value) {
-	if len(m) < 10000 { // 防止极端情况下的内存泄漏
-		visitedPool.Put(m)
-	}
-}
-
 // copierCache 定义
 type copierCache map[reflect.Type]*typeCopier
 
 // Copier 配置
 type Copier struct {
-	useCOW         bool
-	cache          atomic.Pointer[copierCache]
-	muCache        sync.RWMutex
-	mapCache       copierCache
-	cacheInit      sync.Once
-	handleCycle    bool
-	copyUnexported bool
+	useCOW      bool
+	plans       atomic.Pointer[planSet] // 当前选项对应的计划缓存，默认来自全局注册表；nil 表示尚未绑定，见 loadPlans
+	opts        planOptions
+	isolated    bool
+	handleCycle bool
+	par         *parallelPool    // nil 表示顺序拷贝
+	alloc       func() Allocator // nil 表示使用 reflect.New
+	weakPolicy  WeakPolicy
+	lockAware   bool
 }
 
 // New 创建 Copier（COW 模式，适合类型 < 1000）
 func New() *Copier {
 	c := &Copier{
-		useCOW:         true,
-		handleCycle:    true,
-		copyUnexported: false,
+		useCOW:      true,
+		handleCycle: true,
 	}
-	empty := make(copierCache, 64) // 预分配初始容量
-	c.cache.Store(&empty)
+	c.rebind()
 	return c
 }
 
 // NewHighVolume 创建 Copier（Mutex 模式，适合类型 > 1000）
 func NewHighVolume() *Copier {
-	return &Copier{
-		useCOW:         false,
-		handleCycle:    true,
-		copyUnexported: false,
-		mapCache:       nil,
+	c := &Copier{
+		useCOW:      false,
+		handleCycle: true,
 	}
+	c.rebind()
+	return c
 }
 
 func (c *Copier) SetCopyUnexported(enable bool) *Copier {
-	c.copyUnexported = enable
+	c.opts.copyUnexported = enable
+	c.rebind()
 	return c
 }
 
@@ -127,8 +150,16 @@ func (c *Copier) SetHandleCycle(enable bool) *Copier {
 	return c
 }
 
+// SetStrict 开启严格模式：会被静默丢弃的数据（chan/func/unsafe.Pointer、
+// 未开启 copyUnexported 时的未导出字段）不再置零，而是返回错误
+func (c *Copier) SetStrict(enable bool) *Copier {
+	c.opts.strict = enable
+	c.rebind()
+	return c
+}
+
 // Copy 执行深拷贝
-func (c *Copier) Copy(dst, src interface{}) error {
+func (c *Copier) Copy(dst, src interface{}) (err error) {
 	if dst == nil || src == nil {
 		return fmt.Errorf("dst and src must be non-nil")
 	}
@@ -156,14 +187,50 @@ func (c *Copier) Copy(dst, src interface{}) error {
 		return fmt.Errorf("type mismatch: src=%v, dst=%v", srcElem.Type(), dstElem.Type())
 	}
 
-	var visited map[visitKey]reflect.Value
-	if c.handleCycle {
-		visited = acquireVisited()
-		defer releaseVisited(visited)
+	tc := c.getTypeCopier(srcElem.Type())
+	if tc.err != nil {
+		return tc.err
+	}
+	// POD 根对象：直接搬进 dst，不经过中间值，零额外分配
+	if tc.podValue() {
+		copyPOD(dstElem, srcElem, tc.typ.Size())
+		return nil
 	}
 
-	tc := c.getTypeCopier(srcElem.Type())
-	copied := tc.copy(srcElem, visited, c)
+	st := c.newCopyState(tc)
+	if st.visited != nil {
+		defer releaseVisitTable(st.visited)
+	}
+
+	defer recoverCopyError(&err)
+	same := srcVal.Kind() == reflect.Ptr && srcVal.Pointer() == dstVal.Pointer()
+	if tc.kind == kindBuiltin && same {
+		return nil // 拷给自己：内容不变，list.List 等也无法经临时值搬到原地
+	}
+	unlock := st.lockRoot(tc, srcVal)
+	defer unlock()
+	if tc.inPlace && !same {
+		// 按值含有 list.List 等自指针的值只能建在最终地址上：先备份 dst，
+		// 拷贝出错时原样放回，dst 与其他路径一样保持不变
+		backup := reflect.New(tc.typ).Elem()
+		backup.Set(dstElem)
+		dstElem.SetZero()
+		defer func() {
+			if r := recover(); r != nil {
+				dstElem.Set(backup)
+				panic(r)
+			}
+		}()
+		tc.copyAt(dstElem, srcElem, &st)
+		unlock()
+		st.drainLocks()
+		st.resolveWeak()
+		return nil
+	}
+	copied := tc.copy(srcElem, &st)
+	unlock()
+	st.drainLocks()
+	st.resolveWeak() // 在搬进 dst 之前回填，弱指针仍在 copied 里
 	dstElem.Set(copied)
 	return nil
 }
@@ -177,7 +244,7 @@ func Clone(src interface{}) (interface{}, error) {
 }
 
 // Clone 方法
-func (c *Copier) Clone(src interface{}) (interface{}, error) {
+func (c *Copier) Clone(src interface{}) (_ interface{}, err error) {
 	if src == nil {
 		return nil, nil
 	}
@@ -186,17 +253,37 @@ func (c *Copier) Clone(src interface{}) (interface{}, error) {
 		return nil, nil
 	}
 
-	var visited map[visitKey]reflect.Value
-	if c.handleCycle {
-		visited = acquireVisited()
-		defer releaseVisited(visited)
+	tc := c.getTypeCopier(srcVal.Type())
+	if tc.err != nil {
+		return nil, tc.err
 	}
 
-	tc := c.getTypeCopier(srcVal.Type())
-	dst := tc.copy(srcVal, visited, c)
+	st := c.newCopyState(tc)
+	if st.visited != nil {
+		defer releaseVisitTable(st.visited)
+	}
+
+	defer recoverCopyError(&err)
+	dst := tc.copy(srcVal, &st)
+	st.drainLocks()
+	st.resolveWeak() // 在装箱之前回填
 	return dst.Interface(), nil
 }
 
+// copyError 拷贝过程中（而非编译期）发现的错误，以 panic 方式跳出递归，
+// 由入口函数统一 recover 转换为 error 返回
+type copyError struct{ err error }
+
+func recoverCopyError(errp *error) {
+	if r := recover(); r != nil {
+		ce, ok := r.(copyError)
+		if !ok {
+			panic(r)
+		}
+		*errp = ce.err
+	}
+}
+
 var (
 	globalCopier     *Copier
 	globalCopierOnce sync.Once
@@ -204,144 +291,386 @@ var (
 
 // getTypeCopier 获取类型处理器
 func (c *Copier) getTypeCopier(t reflect.Type) *typeCopier {
-	if c.useCOW {
-		return c.getTypeCopierCOW(t)
-	}
-	return c.getTypeCopierMutex(t)
+	return c.loadPlans().getTypeCopier(t)
 }
 
-// getTypeCopierCOW: COW 模式
-func (c *Copier) getTypeCopierCOW(t reflect.Type) *typeCopier {
-	// 快路径：无锁读
-	if m := c.cache.Load(); m != nil {
-		if tc, ok := (*m)[t]; ok {
-			return tc
-		}
+func (ps *planSet) getTypeCopier(t reflect.Type) *typeCopier {
+	if tc := ps.lookupTypeCopier(t); tc != nil {
+		return tc
 	}
+	b := ps.newPlanBuilder()
+	tc := b.resolve(t)
+	b.publish()
+	return tc
+}
 
-	// 慢路径：加锁
-	c.muCache.Lock()
-
-	// 双检
-	if m := c.cache.Load(); m != nil {
-		if tc, ok := (*m)[t]; ok {
-			c.muCache.Unlock()
-			return tc
+// lookupTypeCopier 只查已发布的缓存，未命中返回 nil
+func (ps *planSet) lookupTypeCopier(t reflect.Type) *typeCopier {
+	if ps.useCOW {
+		// 快路径：无锁读
+		if m := ps.cache.Load(); m != nil {
+			return (*m)[t]
 		}
+		return nil
 	}
 
-	// 创建占位符（关键：防止递归死锁）
-	tc := c.createPlaceholder(t)
-
-	// 发布占位符（让其他 goroutine 可见）
-	oldPtr := c.cache.Load()
-	newCache := make(copierCache, len(*oldPtr)+1)
-	for k, v := range *oldPtr {
-		newCache[k] = v
-	}
-	newCache[t] = tc
-	c.cache.Store(&newCache)
-	c.muCache.Unlock()
-
-	// 锁外填充（允许递归）
-	c.fillTypeCopier(tc, t)
-
-	// 更新为完整版本（可选优化：原地修改，因为 tc 是指针）
+	ps.cacheInit.Do(func() {
+		ps.mapCache = make(copierCache, 1024) // 预分配
+	})
+	ps.muCache.RLock()
+	tc := ps.mapCache[t]
+	ps.muCache.RUnlock()
 	return tc
 }
 
-// getTypeCopierMutex: Mutex 模式
-func (c *Copier) getTypeCopierMutex(t reflect.Type) *typeCopier {
-	c.cacheInit.Do(func() {
-		c.mapCache = make(copierCache, 1024) // 预分配
-	})
+// planBuilder 一次编译会话：整张可达类型图先在本地编译完成，再一次性发布。
+// 好处有二：COW 模式下 n 个新类型只拷贝一次 map（而不是 n 次）；
+// 其他 goroutine 永远看不到未填充完的占位符。
+type planBuilder struct {
+	ps      *planSet
+	pending copierCache
+	order   []*typeCopier // 按创建顺序记录，用于发布后的图分析
+}
+
+func (ps *planSet) newPlanBuilder() *planBuilder {
+	return &planBuilder{ps: ps, pending: make(copierCache, 8)}
+}
 
-	// 快路径：RLock
-	c.muCache.RLock()
-	if tc, ok := c.mapCache[t]; ok && tc.isComplete() {
-		c.muCache.RUnlock()
+// resolve 返回 t 的 typeCopier：已发布的直接复用，否则在本会话内创建并递归填充
+func (b *planBuilder) resolve(t reflect.Type) *typeCopier {
+	if tc := b.ps.lookupTypeCopier(t); tc != nil {
 		return tc
 	}
-	c.muCache.RUnlock()
+	if tc, ok := b.pending[t]; ok {
+		return tc // 递归类型：返回尚在填充中的占位符
+	}
 
-	// 慢路径：Lock
-	c.muCache.Lock()
+	tc := b.ps.createPlaceholder(t)
+	b.pending[t] = tc
+	b.order = append(b.order, tc)
+	b.ps.fillTypeCopier(tc, t, b)
+	return tc
+}
 
-	// 双检
-	if tc, ok := c.mapCache[t]; ok && tc.isComplete() {
-		c.muCache.Unlock()
-		return tc
+// publish 把本会话编译的全部 typeCopier 一次性写入缓存
+func (b *planBuilder) publish() {
+	if len(b.pending) == 0 {
+		return
+	}
+	propagateErrors(b.order)
+	analyzeIdentity(b.order)
+	analyzeHooks(b.order)
+	analyzeInPlace(b.order)
+	analyzeWeak(b.order)
+
+	ps := b.ps
+	ps.muCache.Lock()
+	defer ps.muCache.Unlock()
+
+	if ps.useCOW {
+		oldPtr := ps.cache.Load()
+		newCache := make(copierCache, len(*oldPtr)+len(b.pending))
+		for k, v := range *oldPtr {
+			newCache[k] = v
+		}
+		for k, v := range b.pending {
+			// 并发编译同一类型时保留先发布者，两份计划等价
+			if _, ok := newCache[k]; !ok {
+				newCache[k] = v
+			}
+		}
+		ps.cache.Store(&newCache)
+		return
 	}
 
-	// 创建并填充
-	tc := c.createPlaceholder(t)
-	c.mapCache[t] = tc
-	c.muCache.Unlock()
-
-	c.fillTypeCopier(tc, t)
-	return tc
+	for k, v := range b.pending {
+		if _, ok := ps.mapCache[k]; !ok {
+			ps.mapCache[k] = v
+		}
+	}
 }
 
 // createPlaceholder 创建占位符 typeCopier
-func (c *Copier) createPlaceholder(t reflect.Type) *typeCopier {
+func (ps *planSet) createPlaceholder(t reflect.Type) *typeCopier {
 	tc := &typeCopier{
-		typ:  t,
-		kind: kindFromType(t),
+		typ:   t,
+		rtype: rtypeOf(t),
+		kind:  kindFromType(t),
+	}
+	if t == syncMapType {
+		tc.kind = kindSyncMap
+	}
+	if tc.aop = atomicOpOf(t); tc.aop != atomicNone {
+		tc.kind = kindAtomic
+	}
+	if tc.bp = builtinPlanOf(t); tc.bp != nil {
+		tc.kind = kindBuiltin
+	} else if tc.bp = ps.opts.marshalPlanOf(t); tc.bp != nil {
+		tc.kind = kindBuiltin // 序列化回退，见 SetMarshalFallback
+	}
+	if ps.opts.shares(t) {
+		tc.kind = kindShare
+	}
+	if r := ps.opts.typeRule(t); r != Deep {
+		tc.kind = ruleKind(r)
 	}
 
-	// 预计算 isPOD（适用于 Slice/Array）
 	switch tc.kind {
-	case kindSlice, kindArray:
-		tc.isPOD = isPlainOldData(t.Elem())
-		if tc.kind == kindArray {
-			tc.arrayLen = int32(t.Len())
-		}
+	case kindBasic:
+		tc.isPOD = isPlainOldData(t)
+		tc.flat = true
+	case kindArray:
+		tc.arrayLen = int32(t.Len())
+	case kindMap, kindSlice:
+		tc.json = t == jsonObjectType || t == jsonArrayType
+	case kindShare:
+		tc.flat = true // 共享即预期的拷贝结果，浅拷贝等价于深拷贝
 	}
 
 	return tc
 }
 
-// isComplete 检查 typeCopier 是否已填充完成
-func (tc *typeCopier) isComplete() bool {
-	switch tc.kind {
-	case kindBasic, kindUnsupported, kindInterface:
-		return true
-	case kindPtr, kindSlice, kindArray:
-		return tc.elem != nil
-	case kindMap:
-		return tc.key != nil && tc.elem != nil
-	case kindStruct:
-		return tc.fields != nil
-	}
-	return false
-}
-
 // fillTypeCopier 填充 typeCopier 的递归字段（锁外执行）
-func (c *Copier) fillTypeCopier(tc *typeCopier, t reflect.Type) {
+func (ps *planSet) fillTypeCopier(tc *typeCopier, t reflect.Type, b *planBuilder) {
+	opts := &ps.opts
+
 	switch tc.kind {
 	case kindPtr:
-		tc.elem = c.getTypeCopier(t.Elem())
+		tc.elem = b.resolve(t.Elem())
 	case kindSlice:
-		tc.elem = c.getTypeCopier(t.Elem())
+		tc.elem = b.resolve(t.Elem())
+		tc.isPOD = tc.elem.podValue()
 	case kindArray:
-		tc.elem = c.getTypeCopier(t.Elem())
+		tc.elem = b.resolve(t.Elem())
+		tc.isPOD = tc.elem.podValue()
+		tc.flat = tc.elem.flat
 	case kindMap:
-		tc.key = c.getTypeCopier(t.Key())
-		tc.elem = c.getTypeCopier(t.Elem())
+		tc.key = b.resolve(t.Key())
+		tc.elem = b.resolve(t.Elem())
+		tc.isPOD = tc.key.flat && tc.elem.flat
 	case kindStruct:
-		fields := make([]fieldCopier, t.NumField())
+		// 编译期决定每个字段的处理方式：跳过的未导出字段不进入 fields，拷贝时零开销
+		// 同步原语的未导出状态一律重置，不受 copyUnexported 影响
+		reset := isResetType(t)
+		tc.lk = lockKindOf(t)
+		fields := make([]fieldCopier, 0, t.NumField())
 		for i := 0; i < t.NumField(); i++ {
 			f := t.Field(i)
-			fields[i] = fieldCopier{
+			exported := f.PkgPath == ""
+			if !exported && reset {
+				continue
+			}
+			rule := opts.fieldRule(t, f.Name)
+			if rule == Skip {
+				continue
+			}
+			if !exported {
+				if ok, denied := opts.unexportedPolicy(f.PkgPath); !ok {
+					// 未导出的锁本就应该重置，规则要求置零或跳过的字段也一样，跳过它们不算丢数据
+					if opts.strict && tc.err == nil && !isResetType(f.Type) && rule != Zero && !opts.zeroes(f.Type) {
+						reason := "would be dropped"
+						if denied {
+							reason = "is denied by DenyUnexported"
+						}
+						tc.err = fmt.Errorf("deepcopy: strict mode: unexported field %v.%s %s", t, f.Name, reason)
+					}
+					continue
+				}
+			}
+			var copier *typeCopier
+			if rule == Deep {
+				copier = b.resolve(f.Type)
+			} else {
+				copier = ruleCopier(f.Type, rule)
+			}
+			if copier.kind == kindSkip {
+				continue // 类型级 Skip：字段不进入计划
+			}
+			fields = append(fields, fieldCopier{
 				index:     int32(i),
 				offset:    f.Offset,
-				canSet:    f.PkgPath == "",
-				copier:    c.getTypeCopier(f.Type),
+				canSet:    exported,
+				copier:    copier,
 				fieldType: f.Type,
-			}
+			})
 		}
 		tc.fields = &fields
+		for i := range fields {
+			tc.needsAddr = tc.needsAddr || !fields[i].canSet
+		}
+		// 有钩子的结构体不能整块搬运，否则会绕过钩子
+		tc.hk = hooksOf(t)
+		tc.isPOD = tc.hk == 0 && isPlainOldData(t) && len(fields) == t.NumField() && fieldsArePOD(fields)
+		tc.flat = tc.hk == 0 && len(fields) == t.NumField() && fieldsAreFlat(fields)
+	case kindSyncMap:
+		tc.elem = b.resolve(anyType)
+	case kindBuiltin:
+		if tc.bp.elem != nil {
+			tc.elem = b.resolve(tc.bp.elem)
+		}
+	case kindAtomic:
+		switch tc.aop {
+		case atomicPointer:
+			tc.elem = b.resolve(atomicPointerTarget(t))
+		case atomicValue:
+			tc.elem = b.resolve(anyType)
+		}
+	case kindUnsupported:
+		if opts.strict {
+			tc.err = fmt.Errorf("deepcopy: strict mode: %v cannot be deep-copied", t)
+		}
+	case kindBasic:
+		if opts.strict && t.Kind() == reflect.UnsafePointer {
+			tc.err = fmt.Errorf("deepcopy: strict mode: %v cannot be deep-copied", t)
+		}
+	}
+}
+
+// podValue 该类型的值本身可整块 memmove（Slice/Map 的 isPOD 描述的是元素，不算）
+func (tc *typeCopier) podValue() bool {
+	return tc.isPOD && tc.kind != kindSlice && tc.kind != kindMap
+}
+
+// fieldsArePOD 所有字段计划都可整块拷贝（POD 类型不含指针，不会引用未填充的占位符）
+func fieldsArePOD(fields []fieldCopier) bool {
+	for i := range fields {
+		if !fields[i].copier.podValue() {
+			return false
+		}
 	}
+	return true
+}
+
+func fieldsAreFlat(fields []fieldCopier) bool {
+	for i := range fields {
+		if !fields[i].copier.flat {
+			return false
+		}
+	}
+	return true
+}
+
+// propagateErrors 让错误沿类型图向上传播：引用了出错类型的计划同样出错。
+// 递归类型可能形成环，因此迭代到不动点为止。
+func propagateErrors(order []*typeCopier) {
+	for changed := true; changed; {
+		changed = false
+		for _, tc := range order {
+			if tc.err != nil {
+				continue
+			}
+			if err := tc.childErr(); err != nil {
+				tc.err = err
+				changed = true
+			}
+		}
+	}
+}
+
+// analyzeIdentity 编译期证明类型图能否出现环或共享引用。
+// 证明不可能时（needsVisit=false），拷贝时完全跳过 visited 表，
+// 调用方无需手动 SetHandleCycle(false)。
+func analyzeIdentity(order []*typeCopier) {
+	for _, tc := range order {
+		tc.hasRefs = tc.kind == kindPtr || tc.kind == kindMap || tc.kind == kindInterface || tc.kind == kindSyncMap ||
+			tc.kind == kindBuiltin && tc.bp.identity
+	}
+	// 递归类型可能形成环，迭代到不动点
+	for changed := true; changed; {
+		changed = false
+		for _, tc := range order {
+			if !tc.hasRefs && tc.anyChild(func(child *typeCopier) bool { return child.hasRefs }) {
+				tc.hasRefs = true
+				changed = true
+			}
+		}
+	}
+	for _, tc := range order {
+		tc.needsVisit = mayRevisit(tc)
+	}
+}
+
+// mayRevisit 从 root 出发，判断一次拷贝中是否可能两次到达同一个指针/map：
+// 接口的动态类型未知；同一引用类型出现在两条路径上，或位于切片/数组/map
+// 元素这类"多份"位置时，都可能共享或成环。
+func mayRevisit(root *typeCopier) bool {
+	seen := make(map[*typeCopier]bool)
+	var walk func(tc *typeCopier, many bool) bool
+	walk = func(tc *typeCopier, many bool) bool {
+		if !tc.hasRefs {
+			return false
+		}
+		switch tc.kind {
+		case kindInterface, kindSyncMap:
+			return true
+		case kindPtr:
+			if many || seen[tc] {
+				return true
+			}
+			seen[tc] = true
+			return walk(tc.elem, false)
+		case kindMap:
+			if many || seen[tc] {
+				return true
+			}
+			seen[tc] = true
+			return walk(tc.key, true) || walk(tc.elem, true)
+		case kindSlice:
+			return walk(tc.elem, true)
+		case kindArray:
+			return walk(tc.elem, many || tc.arrayLen > 1)
+		case kindAtomic:
+			// atomic.Pointer/Value 与其载入的目标视同一个指针/接口
+			return tc.elem != nil && walk(tc.elem, many)
+		case kindBuiltin:
+			// 容器类内部可能有任意多个元素
+			return tc.bp.identity || tc.elem != nil && walk(tc.elem, true)
+		case kindStruct:
+			for i := range *tc.fields {
+				if walk((*tc.fields)[i].copier, many) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return walk(root, false)
+}
+
+// anyChild 对直接子计划求存在量词
+func (tc *typeCopier) anyChild(pred func(*typeCopier) bool) bool {
+	if tc.key != nil && pred(tc.key) {
+		return true
+	}
+	if tc.elem != nil && pred(tc.elem) {
+		return true
+	}
+	if tc.fields != nil {
+		for i := range *tc.fields {
+			if pred((*tc.fields)[i].copier) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// childErr 返回直接子计划的第一个错误
+func (tc *typeCopier) childErr() error {
+	if tc.key != nil && tc.key.err != nil {
+		return tc.key.err
+	}
+	if tc.elem != nil && tc.elem.err != nil {
+		return tc.elem.err
+	}
+	if tc.fields != nil {
+		for i := range *tc.fields {
+			if err := (*tc.fields)[i].copier.err; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
 func kindFromType(t reflect.Type) copierKind {
@@ -366,61 +695,98 @@ func kindFromType(t reflect.Type) copierKind {
 }
 
 // copy 执行拷贝（使用指针接收者，避免值拷贝）
-func (tc *typeCopier) copy(src reflect.Value, visited map[visitKey]reflect.Value, c *Copier) reflect.Value {
+func (tc *typeCopier) copy(src reflect.Value, st *copyState) reflect.Value {
 	switch tc.kind {
 	case kindBasic:
 		return src // 零开销
 	case kindPtr:
-		return tc.copyPtr(src, visited, c)
+		return tc.copyPtr(src, st)
 	case kindSlice:
-		return tc.copySlice(src, visited, c)
+		return tc.copySlice(src, st)
 	case kindArray:
-		return tc.copyArray(src, visited, c)
+		return tc.copyArray(src, st)
 	case kindMap:
-		return tc.copyMap(src, visited, c)
+		return tc.copyMap(src, st)
 	case kindStruct:
-		return tc.copyStruct(src, visited, c)
+		return tc.copyStruct(src, st)
 	case kindInterface:
-		return tc.copyInterface(src, visited, c)
-	case kindUnsupported:
+		return tc.copyInterface(src, st)
+	case kindUnsupported, kindZero, kindSkip:
 		return reflect.Zero(tc.typ)
+	case kindShare:
+		return src
+	case kindSyncMap:
+		return tc.copySyncMap(src, st)
+	case kindAtomic:
+		return tc.copyAtomic(src, st)
+	case kindBuiltin:
+		return tc.copyBuiltin(src, st)
 	default:
 		return src
 	}
 }
 
-func (tc *typeCopier) copyPtr(src reflect.Value, visited map[visitKey]reflect.Value, c *Copier) reflect.Value {
+func (tc *typeCopier) copyPtr(src reflect.Value, st *copyState) reflect.Value {
 	if src.IsNil() {
 		return reflect.Zero(tc.typ)
 	}
 
-	if c.handleCycle && visited != nil {
-		ptr := src.Pointer()
-		key := visitKey{ptr: ptr, typ: tc.typ}
-		if cached, ok := visited[key]; ok {
-			return cached
+	if st.visited != nil {
+		dst, fresh := st.claimPtr(src, tc)
+		if fresh {
+			tc.fillElem(dst, src, st)
 		}
-
-		dst := reflect.New(tc.typ.Elem())
-		visited[key] = dst
-
-		copiedElem := tc.elem.copy(src.Elem(), visited, c)
-		dst.Elem().Set(copiedElem)
 		return dst
 	}
 
-	dst := reflect.New(tc.typ.Elem())
-	copiedElem := tc.elem.copy(src.Elem(), visited, c)
-	dst.Elem().Set(copiedElem)
+	dst := st.newElem(tc)
+	tc.fillElem(dst, src, st)
 	return dst
 }
 
+// fillElem 填充新分配的指针目标
+func (tc *typeCopier) fillElem(dst, src reflect.Value, st *copyState) {
+	if st.locks != nil && tc.elem.lk != lockNone {
+		tc.fillLocked(dst, src, false, st)
+		return
+	}
+	tc.elem.copyAt(dst.Elem(), src.Elem(), st)
+}
+
+// copyAt 把 src 深拷贝进可寻址的零值 dst。结构体、数组与标准库专用计划直接写入 dst，
+// 省去临时值，也让 list.List 这类含自指针的值一开始就建在最终地址上
+func (tc *typeCopier) copyAt(dst, src reflect.Value, st *copyState) {
+	switch tc.kind {
+	case kindStruct:
+		tc.fillStruct(dst, src, st)
+	case kindArray:
+		if tc.isPOD {
+			copyPOD(dst, src, tc.typ.Size())
+			return
+		}
+		tc.copyElems(dst, src, int(tc.arrayLen), st)
+	case kindBuiltin:
+		tc.bp.fill(tc, unsafe.Pointer(dst.UnsafeAddr()), srcAddr(src), false, st)
+	case kindInterface:
+		tc.copyInterfaceAt(dst, src, st)
+	case kindZero, kindSkip:
+		// dst 已是零值
+	default:
+		dst.Set(tc.copy(src, st))
+	}
+}
+
 // copySlice: 修复 - 移除 Slice 本身的循环引用检测
-func (tc *typeCopier) copySlice(src reflect.Value, visited map[visitKey]reflect.Value, c *Copier) reflect.Value {
+func (tc *typeCopier) copySlice(src reflect.Value, st *copyState) reflect.Value {
 	if src.IsNil() {
 		return reflect.Zero(tc.typ)
 	}
 
+	if tc.json && src.CanInterface() {
+		j := jsonCloner{st: st, any: tc.elem}
+		return reflect.ValueOf(j.array(src.Interface().([]any)))
+	}
+
 	n := src.Len()
 	dst := reflect.MakeSlice(tc.typ, n, src.Cap())
 
@@ -431,107 +797,251 @@ func (tc *typeCopier) copySlice(src reflect.Value, visited map[visitKey]reflect.
 	}
 
 	// 非 POD：逐元素深拷贝（元素的循环引用由元素自身的 copy 处理）
-	for i := 0; i < n; i++ {
-		copied := tc.elem.copy(src.Index(i), visited, c)
-		dst.Index(i).Set(copied)
-	}
+	tc.copyElems(dst, src, n, st)
 	return dst
 }
 
-// copyArray: 修复 - 逐元素复制避免不可寻址问题
-func (tc *typeCopier) copyArray(src reflect.Value, visited map[visitKey]reflect.Value, c *Copier) reflect.Value {
+// copyArray: POD 数组整块 memmove，其余逐元素深拷贝
+func (tc *typeCopier) copyArray(src reflect.Value, st *copyState) reflect.Value {
 	dst := reflect.New(tc.typ).Elem()
 
-	// POD 快速路径：逐元素复制
+	// POD 快速路径：一次 memmove，无逐元素反射
 	if tc.isPOD {
-		for i := 0; i < int(tc.arrayLen); i++ {
-			dst.Index(i).Set(src.Index(i))
-		}
+		copyPOD(dst, src, tc.typ.Size())
 		return dst
 	}
 
 	// 非 POD：逐元素
-	for i := 0; i < int(tc.arrayLen); i++ {
-		copied := tc.elem.copy(src.Index(i), visited, c)
-		dst.Index(i).Set(copied)
-	}
+	tc.copyElems(dst, src, int(tc.arrayLen), st)
 	return dst
 }
 
-func (tc *typeCopier) copyMap(src reflect.Value, visited map[visitKey]reflect.Value, c *Copier) reflect.Value {
+// copyElems 逐元素深拷贝 Slice/Array 的前 n 个元素；开启并行且超过阈值时分段交给工作池
+func (tc *typeCopier) copyElems(dst, src reflect.Value, n int, st *copyState) {
+	if st.par != nil && n >= st.par.threshold {
+		st.par.run(n, st, func(lo, hi int, st *copyState) {
+			tc.copyElemRange(dst, src, lo, hi, st)
+		})
+		return
+	}
+	tc.copyElemRange(dst, src, 0, n, st)
+}
+
+func (tc *typeCopier) copyElemRange(dst, src reflect.Value, lo, hi int, st *copyState) {
+	if tc.elem.hooked {
+		tc.copyElemRangeHooked(dst, src, lo, hi, false, st)
+		return
+	}
+	for i := lo; i < hi; i++ {
+		tc.elem.copyAt(dst.Index(i), src.Index(i), st)
+	}
+}
+
+// copyPOD 把不含指针的 src 整块搬到可寻址的 dst。
+// 不含指针的内存无需写屏障，runtimeMemmove 对 GC 安全；
+// src 不可寻址时（如 MapIndex 的结果）退回 Set，其内部同样是一次整块拷贝。
+func copyPOD(dst, src reflect.Value, size uintptr) {
+	if size == 0 {
+		return
+	}
+	if src.CanAddr() {
+		runtimeMemmove(unsafe.Pointer(dst.UnsafeAddr()), unsafe.Pointer(src.UnsafeAddr()), size)
+		return
+	}
+	dst.Set(src)
+}
+
+// copyMap 基于 MapRange 迭代：不分配全部 key 的切片，也不对每个 key 二次哈希。
+// NaN key 无法通过 MapIndex 取回，但迭代器能直接给出对应的 value，因此不会丢失。
+func (tc *typeCopier) copyMap(src reflect.Value, st *copyState) reflect.Value {
 	if src.IsNil() {
 		return reflect.Zero(tc.typ)
 	}
 
-	dst := reflect.MakeMapWithSize(tc.typ, src.Len())
+	if tc.json && src.CanInterface() {
+		j := jsonCloner{st: st, any: tc.elem}
+		return reflect.ValueOf(j.object(src.Interface().(map[string]any)))
+	}
+
+	// key 和 value 都是 flat：交给运行时整表克隆（maps.Clone 同款实现）
+	fast := tc.isPOD && src.CanInterface()
 
-	// 立即注册到 visited（关键：防止递归时无限循环）
-	if c.handleCycle && visited != nil {
-		ptr := src.Pointer()
-		key := visitKey{ptr: ptr, typ: tc.typ}
-		if cached, ok := visited[key]; ok {
-			return cached
+	// 先登记再填充（关键：防止递归时无限循环）
+	if st.visited != nil {
+		dst, fresh := st.claimMap(src, tc, func() reflect.Value {
+			if fast {
+				return reflect.ValueOf(mapsClone(src.Interface()))
+			}
+			return reflect.MakeMapWithSize(tc.typ, src.Len())
+		})
+		if fresh && !fast {
+			tc.fillMap(dst, src, st)
 		}
-		visited[key] = dst
+		return dst
 	}
 
-	for _, key := range src.MapKeys() {
-		newKey := tc.key.copy(key, visited, c)
-		newVal := tc.elem.copy(src.MapIndex(key), visited, c)
-		dst.SetMapIndex(newKey, newVal)
+	if fast {
+		return reflect.ValueOf(mapsClone(src.Interface()))
 	}
+	dst := reflect.MakeMapWithSize(tc.typ, src.Len())
+	tc.fillMap(dst, src, st)
 	return dst
 }
 
-func (tc *typeCopier) copyStruct(src reflect.Value, visited map[visitKey]reflect.Value, c *Copier) reflect.Value {
+func (tc *typeCopier) fillMap(dst, src reflect.Value, st *copyState) {
+	if st.par != nil && src.Len() >= st.par.threshold {
+		tc.fillMapParallel(dst, src, st)
+		return
+	}
+
+	// 复用一对可寻址的临时变量接收迭代结果，循环内不再分配
+	tc.fillMapEntries(dst, src, reflect.New(tc.typ.Key()).Elem(), reflect.New(tc.typ.Elem()).Elem(), st)
+}
+
+// fillMapEntries 逐条深拷贝 src 写入 dst，k/v 为接收迭代结果的可寻址临时变量
+func (tc *typeCopier) fillMapEntries(dst, src, k, v reflect.Value, st *copyState) {
+	if tc.hooked {
+		defer traceKey(&k)
+	}
+	weakly := st.visited != nil && (tc.key.holdsWeak || tc.elem.holdsWeak)
+	var mark int32
+	var iter reflect.MapIter
+	iter.Reset(src)
+	for iter.Next() {
+		k.SetIterKey(&iter)
+		v.SetIterValue(&iter)
+		if weakly {
+			mark = st.weakMark()
+		}
+		newKey := tc.key.copy(k, st)
+		newVal := tc.elem.copy(v, st)
+		dst.SetMapIndex(newKey, newVal)
+		if weakly && st.weakMark() != mark {
+			tc.deferWeakEntry(dst, newKey, newVal, st)
+		}
+	}
+}
+
+// fillMapParallel 把条目摊平到两个切片里分段并行深拷贝，最后单线程写回 dst
+// （map 写入不支持并发）
+func (tc *typeCopier) fillMapParallel(dst, src reflect.Value, st *copyState) {
+	n := src.Len()
+	keys := reflect.MakeSlice(reflect.SliceOf(tc.typ.Key()), n, n)
+	vals := reflect.MakeSlice(reflect.SliceOf(tc.typ.Elem()), n, n)
+	iter := src.MapRange()
+	for i := 0; iter.Next(); i++ {
+		keys.Index(i).SetIterKey(iter)
+		vals.Index(i).SetIterValue(iter)
+	}
+
+	weakly := st.visited != nil && (tc.key.holdsWeak || tc.elem.holdsWeak)
+	st.par.run(n, st, func(lo, hi int, st *copyState) {
+		var k reflect.Value
+		if tc.hooked {
+			defer traceKey(&k)
+		}
+		var mark int32
+		for i := lo; i < hi; i++ {
+			if weakly {
+				mark = st.weakMark()
+			}
+			k = keys.Index(i)
+			v := vals.Index(i)
+			newKey := tc.key.copy(k, st)
+			newVal := tc.elem.copy(v, st)
+			k.Set(newKey)
+			v.Set(newVal)
+			// 其他 worker 的登记也会改变 mark，多登记的条目重新写入一次而已
+			if weakly && st.weakMark() != mark {
+				tc.deferWeakEntry(dst, newKey, newVal, st)
+			}
+		}
+	})
+
+	for i := 0; i < n; i++ {
+		dst.SetMapIndex(keys.Index(i), vals.Index(i))
+	}
+}
+
+func (tc *typeCopier) copyStruct(src reflect.Value, st *copyState) reflect.Value {
 	dst := reflect.New(tc.typ).Elem()
+	tc.fillStruct(dst, src, st)
+	return dst
+}
 
-	// 快速路径：无可导出字段且未开启 copyUnexported
+// fillStruct 把 src 逐字段深拷贝进可寻址的零值 dst，
+// copyPtr 用它直接写入分配好的目标，省去一个临时结构体
+func (tc *typeCopier) fillStruct(dst, src reflect.Value, st *copyState) {
+	if tc.hooked {
+		tc.fillStructHooked(dst, src, false, st)
+		return
+	}
+	tc.fillFields(dst, src, st, nil)
+}
+
+// fillFields fillStruct 的字段拷贝部分；cur 非 nil 时记录正在拷贝的字段下标
+func (tc *typeCopier) fillFields(dst, src reflect.Value, st *copyState, cur *int32) {
+	// 快速路径：没有需要拷贝的字段（未导出字段在编译期已按选项剔除）
 	if tc.fields == nil || len(*tc.fields) == 0 {
-		return dst
+		return
+	}
+
+	// POD 结构体：整块 memmove，零逐字段反射
+	if tc.isPOD {
+		copyPOD(dst, src, tc.typ.Size())
+		return
 	}
 
+	if tc.needsAddr && !src.CanAddr() {
+		src = addressable(src)
+	}
 	srcCanAddr := src.CanAddr()
 
 	for i := range *tc.fields {
 		fc := &(*tc.fields)[i] // 使用指针避免拷贝
+		if cur != nil {
+			*cur = fc.index
+		}
 
-		if fc.canSet {
-			copied := fc.copier.copy(src.Field(int(fc.index)), visited, c)
-			dst.Field(int(fc.index)).Set(copied)
-		} else if c.copyUnexported && srcCanAddr {
+		if fc.copier.podValue() && srcCanAddr {
+			// POD 字段（含未导出）：直接按偏移搬运，省去一次 reflect.New
+			runtimeMemmove(unsafe.Add(unsafe.Pointer(dst.UnsafeAddr()), fc.offset),
+				unsafe.Add(unsafe.Pointer(src.UnsafeAddr()), fc.offset), fc.fieldType.Size())
+		} else if fc.canSet {
+			fc.copier.copyAt(dst.Field(int(fc.index)), src.Field(int(fc.index)), st)
+		} else if srcCanAddr {
 			// 未导出字段处理
-			srcPtr := unsafe.Pointer(src.UnsafeAddr() + fc.offset)
+			srcPtr := unsafe.Add(unsafe.Pointer(src.UnsafeAddr()), fc.offset)
 			srcField := reflect.NewAt(fc.fieldType, srcPtr).Elem()
 
-			copied := fc.copier.copy(srcField, visited, c)
-
-			// 确保 copied 可寻址以使用 memmove
-			if !copied.CanAddr() {
-				// 回退到 Set（极少发生）
-				dstPtr := unsafe.Pointer(dst.UnsafeAddr() + fc.offset)
-				dstField := reflect.NewAt(fc.fieldType, dstPtr).Elem()
-				dstField.Set(copied)
-			} else {
-				dstPtr := unsafe.Pointer(dst.UnsafeAddr() + fc.offset)
-				runtimeMemmove(dstPtr, unsafe.Pointer(copied.UnsafeAddr()), fc.fieldType.Size())
-			}
+			// 含指针的字段必须经 Set（typedmemmove）写入，保证 GC 写屏障
+			dstPtr := unsafe.Add(unsafe.Pointer(dst.UnsafeAddr()), fc.offset)
+			dstField := reflect.NewAt(fc.fieldType, dstPtr).Elem()
+			fc.copier.copyAt(dstField, srcField, st)
 		}
 	}
-	return dst
 }
 
-func (tc *typeCopier) copyInterface(src reflect.Value, visited map[visitKey]reflect.Value, c *Copier) reflect.Value {
+// addressable 把不可寻址的值（Clone 传入的结构体值、接口里的值、不可寻址数组的元素等）
+// 复制到临时变量上，未导出字段才能按偏移读取，不会因为到达路径不同而被丢弃
+func addressable(src reflect.Value) reflect.Value {
+	tmp := reflect.New(src.Type()).Elem()
+	tmp.Set(src)
+	return tmp
+}
+
+func (tc *typeCopier) copyInterface(src reflect.Value, st *copyState) reflect.Value {
 	if src.IsNil() {
 		return reflect.Zero(tc.typ)
 	}
 
-	actual := src.Elem()
-	actualType := actual.Type()
-
-	// 获取或创建实际类型的 copier
-	actualCopier := c.getTypeCopier(actualType)
-	copied := actualCopier.copy(actual, visited, c)
+	mark := st.weakMark()
+	copied := tc.copyDynamic(src.Elem(), st)
+	if st.weakMark() != mark && copied.CanAddr() {
+		iv := reflect.New(tc.typ).Elem()
+		boxWeak(iv, copied, mark, st)
+		return iv
+	}
 
 	// 转换回接口类型（如果必要）
 	if copied.Type() != tc.typ {
@@ -540,6 +1050,32 @@ func (tc *typeCopier) copyInterface(src reflect.Value, visited map[visitKey]refl
 	return copied
 }
 
+// copyInterfaceAt 把 src 的拷贝直接写进可寻址的接口变量 dst，
+// 接口里的弱指针在回填时写到 dst 上，而不是随后被丢弃的临时变量
+func (tc *typeCopier) copyInterfaceAt(dst, src reflect.Value, st *copyState) {
+	if src.IsNil() {
+		dst.SetZero()
+		return
+	}
+	mark := st.weakMark()
+	copied := tc.copyDynamic(src.Elem(), st)
+	if st.weakMark() != mark && copied.CanAddr() {
+		boxWeak(dst, copied, mark, st)
+		return
+	}
+	dst.Set(copied)
+}
+
+// copyDynamic 按动态类型拷贝接口里取出的值（tc 为接口类型的计划）
+func (tc *typeCopier) copyDynamic(actual reflect.Value, st *copyState) reflect.Value {
+	// 获取或创建实际类型的 copier（先查内联缓存）
+	actualCopier := tc.dynamicCopier(actual.Type(), st)
+	if actualCopier.err != nil {
+		panic(copyError{actualCopier.err})
+	}
+	return actualCopier.copy(actual, st)
+}
+
 func isPlainOldData(t reflect.Type) bool {
 	switch t.Kind() {
 	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
@@ -548,6 +1084,13 @@ func isPlainOldData(t reflect.Type) bool {
 		return true
 	case reflect.Array:
 		return isPlainOldData(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if !isPlainOldData(t.Field(i).Type) {
+				return false
+			}
+		}
+		return true
 	default:
 		return false
 	}
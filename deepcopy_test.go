@@ -1,14 +1,28 @@
 package deepcopy
 
 import (
+	"bytes"
+	"container/list"
+	"container/ring"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"math"
+	"math/big"
+	"net"
+	"net/netip"
+	"os"
 	"reflect"
+	"runtime"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 	"unsafe"
+	"weak"
 )
 
 // ============================================================================
@@ -680,7 +694,6 @@ func TestStructs(t *testing.T) {
 			Public  string
 			private string
 		}
-		// 为了复制未导出字段，src 必须是可寻址的（指针）
 		src := &Secret{Public: "visible", private: "hidden"}
 		var dst Secret
 
@@ -1311,8 +1324,10 @@ func TestEdgeCases(t *testing.T) {
 		if dst.Public != 42 {
 			t.Error("public field wrong")
 		}
-		// 注意：由于 src 是通过值传递的，不可寻址，未导出字段无法复制
-		// 这是设计限制，只有可寻址的值才能复制未导出字段
+		// src 按值传递、不可寻址，先落到临时变量上再按偏移拷贝未导出字段
+		if dst.big[0] != 1 || dst.big[len(dst.big)-1] != 2 {
+			t.Error("unexported field of non-addressable source not copied")
+		}
 	})
 
 	t.Run("zero_value_struct", func(t *testing.T) {
@@ -1393,3 +1408,4018 @@ func ExampleCopier_Copy() {
 
 // 确保测试编译
 var _ = json.Marshal
+
+// ============================================================================
+// 预编译测试
+// ============================================================================
+
+func TestPrecompile(t *testing.T) {
+	type Leaf struct {
+		V []int
+	}
+	type Node struct {
+		Name     string
+		Children []*Node
+		Attrs    map[string]Leaf
+	}
+
+	t.Run("compiles_reachable_graph", func(t *testing.T) {
+		for _, c := range []*Copier{New(), NewHighVolume()} {
+			if err := c.Precompile(reflect.TypeOf(Node{})); err != nil {
+				t.Fatal(err)
+			}
+			for _, typ := range []reflect.Type{
+				reflect.TypeOf(Node{}),
+				reflect.TypeOf(&Node{}),
+				reflect.TypeOf([]*Node{}),
+				reflect.TypeOf(map[string]Leaf{}),
+				reflect.TypeOf(Leaf{}),
+				reflect.TypeOf([]int{}),
+			} {
+				if c.loadPlans().lookupTypeCopier(typ) == nil {
+					t.Errorf("%v not compiled", typ)
+				}
+			}
+		}
+	})
+
+	t.Run("single_cow_publish", func(t *testing.T) {
+		c := New().SetSharedPlans(false)
+		before := c.loadPlans().cache.Load()
+		if err := c.Precompile(reflect.TypeOf(Node{}), reflect.TypeOf(Leaf{})); err != nil {
+			t.Fatal(err)
+		}
+		after := c.loadPlans().cache.Load()
+		if before == after {
+			t.Fatal("cache not published")
+		}
+		// 再次预编译已缓存的类型不应重新发布
+		if err := c.Precompile(reflect.TypeOf(Node{})); err != nil {
+			t.Fatal(err)
+		}
+		if c.loadPlans().cache.Load() != after {
+			t.Error("cached types were published again")
+		}
+	})
+
+	t.Run("warm_generic", func(t *testing.T) {
+		c := New()
+		if err := Warm[Node](c); err != nil {
+			t.Fatal(err)
+		}
+		if c.loadPlans().lookupTypeCopier(reflect.TypeOf([]*Node{})) == nil {
+			t.Error("Warm did not compile nested types")
+		}
+
+		src := &Node{Name: "root", Children: []*Node{{Name: "child"}}}
+		var dst Node
+		if err := c.Copy(&dst, src); err != nil {
+			t.Fatal(err)
+		}
+		if dst.Children[0].Name != "child" || dst.Children[0] == src.Children[0] {
+			t.Error("copy after warm-up wrong")
+		}
+	})
+
+	t.Run("nil_type", func(t *testing.T) {
+		if err := New().Precompile(nil); err == nil {
+			t.Error("expected error for nil type")
+		}
+	})
+
+	t.Run("strict_rejects", func(t *testing.T) {
+		type WithFunc struct {
+			Fn func()
+		}
+		type Outer struct {
+			Inner []WithFunc
+		}
+		type Hidden struct {
+			Public  int
+			private int
+		}
+
+		c := New().SetStrict(true)
+		if err := c.Precompile(reflect.TypeOf(Outer{})); err == nil {
+			t.Error("expected error for nested func field")
+		}
+		if err := Warm[Hidden](c); err == nil {
+			t.Error("expected error for dropped unexported field")
+		}
+		if err := Warm[Hidden](New().SetStrict(true).SetCopyUnexported(true)); err != nil {
+			t.Errorf("unexported copying enabled, got %v", err)
+		}
+		if err := Warm[Node](c); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		// 非严格模式保持原有行为：静默置零
+		if err := Warm[Outer](New()); err != nil {
+			t.Errorf("non-strict mode should not fail: %v", err)
+		}
+	})
+
+	t.Run("strict_recursive_type", func(t *testing.T) {
+		type Rec struct {
+			Next *Rec
+			Fn   func()
+		}
+		c := New().SetStrict(true)
+		if err := Warm[*Rec](c); err == nil {
+			t.Error("error not propagated through recursive type")
+		}
+	})
+
+	t.Run("strict_dynamic_type", func(t *testing.T) {
+		c := New().SetStrict(true)
+		var src interface{} = []interface{}{1, func() {}}
+		if _, err := c.Clone(src); err == nil {
+			t.Error("expected error for func inside interface")
+		}
+	})
+}
+
+func BenchmarkPrecompile(b *testing.B) {
+	type Leaf struct{ V []int }
+	type Node struct {
+		Children []*Node
+		Attrs    map[string]Leaf
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := Warm[Node](New()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// ============================================================================
+// 共享计划注册表测试
+// ============================================================================
+
+func TestSharedPlans(t *testing.T) {
+	type Payload struct {
+		Items []string
+		Next  *Payload
+	}
+	typ := reflect.TypeOf(Payload{})
+
+	t.Run("equal_options_share_plans", func(t *testing.T) {
+		a := New()
+		b := New()
+		if a.loadPlans() != b.loadPlans() {
+			t.Fatal("copiers with equal options use different plan sets")
+		}
+		if err := a.Precompile(typ); err != nil {
+			t.Fatal(err)
+		}
+		if b.loadPlans().lookupTypeCopier(typ) != a.loadPlans().lookupTypeCopier(typ) {
+			t.Error("compiled plan not reused")
+		}
+	})
+
+	t.Run("different_options_do_not_share", func(t *testing.T) {
+		a := New()
+		b := New().SetCopyUnexported(true)
+		if a.loadPlans() == b.loadPlans() {
+			t.Error("different options share plan set")
+		}
+		if New().SetStrict(true).loadPlans() == a.loadPlans() {
+			t.Error("strict copier shares plan set with default copier")
+		}
+		if NewHighVolume().loadPlans() == a.loadPlans() {
+			t.Error("COW and mutex copiers share plan set")
+		}
+		// 切换回相同选项后重新共享
+		if b.SetCopyUnexported(false).loadPlans() != a.loadPlans() {
+			t.Error("resetting options did not rebind to shared plan set")
+		}
+	})
+
+	t.Run("isolated_opt_out", func(t *testing.T) {
+		a := New()
+		b := New().SetSharedPlans(false)
+		if a.loadPlans() == b.loadPlans() {
+			t.Fatal("isolated copier uses shared plan set")
+		}
+		if err := a.Precompile(typ); err != nil {
+			t.Fatal(err)
+		}
+		if b.loadPlans().lookupTypeCopier(typ) != nil {
+			t.Error("isolated cache sees shared plans")
+		}
+		// 隔离状态下修改选项仍保持隔离
+		b.SetCopyUnexported(true)
+		if b.loadPlans() == New().SetCopyUnexported(true).loadPlans() {
+			t.Error("isolated copier rebound to shared plan set")
+		}
+	})
+
+	t.Run("options_take_effect_after_compile", func(t *testing.T) {
+		type Secret struct {
+			Public  string
+			private string
+		}
+		c := New()
+		src := &Secret{Public: "a", private: "b"}
+		var dst Secret
+		if err := c.Copy(&dst, src); err != nil {
+			t.Fatal(err)
+		}
+		if dst.private != "" {
+			t.Fatal("unexported field copied by default")
+		}
+		c.SetCopyUnexported(true)
+		if err := c.Copy(&dst, src); err != nil {
+			t.Fatal(err)
+		}
+		if dst.private != "b" {
+			t.Error("SetCopyUnexported(true) ignored after first compile")
+		}
+	})
+
+	t.Run("builder_chain_registers_once", func(t *testing.T) {
+		registered := func() int {
+			planRegistry.mu.Lock()
+			defer planRegistry.mu.Unlock()
+			return len(planRegistry.sets)
+		}
+		type Marker struct{ N int }
+		before := registered()
+		c := New().SetStrict(true).SetCopyUnexported(true).
+			ShareIdentity(reflect.TypeFor[*Marker]()).AllowUnexported("example.com/chain/...")
+		if n := registered(); n != before {
+			t.Fatalf("configuration chain registered %d plan sets before first use", n-before)
+		}
+		if _, err := c.Clone(&Marker{N: 1}); err != nil {
+			t.Fatal(err)
+		}
+		if n := registered(); n != before+1 {
+			t.Errorf("first use registered %d plan sets, want 1", n-before)
+		}
+	})
+}
+
+// ============================================================================
+// POD 结构体/数组整块拷贝测试
+// ============================================================================
+
+type podVec struct {
+	X, Y, Z float64
+}
+
+type podParticle struct {
+	ID    uint64
+	Pos   podVec
+	Vel   podVec
+	Flags [4]bool
+	Hist  [16]int32
+}
+
+func TestPODStructs(t *testing.T) {
+	t.Run("plan_marks_pod", func(t *testing.T) {
+		c := New()
+		if !c.getTypeCopier(reflect.TypeOf(podParticle{})).isPOD {
+			t.Error("scalar-only struct not POD")
+		}
+		if !c.getTypeCopier(reflect.TypeOf([8]podParticle{})).isPOD {
+			t.Error("array of POD structs not POD")
+		}
+		type WithString struct {
+			ID   int
+			Name string
+		}
+		if c.getTypeCopier(reflect.TypeOf(WithString{})).isPOD {
+			t.Error("struct with string marked POD")
+		}
+		if c.getTypeCopier(reflect.TypeOf([2][]int{})).isPOD {
+			t.Error("array of slices marked POD")
+		}
+	})
+
+	t.Run("values_copied", func(t *testing.T) {
+		c := New()
+		src := podParticle{ID: 7, Pos: podVec{1, 2, 3}, Vel: podVec{4, 5, 6}}
+		src.Flags[3] = true
+		src.Hist[15] = -1
+
+		var dst podParticle
+		if err := c.Copy(&dst, &src); err != nil {
+			t.Fatal(err)
+		}
+		if dst != src {
+			t.Errorf("got %+v, want %+v", dst, src)
+		}
+
+		// 不可寻址源（值传递）
+		var dst2 podParticle
+		if err := c.Copy(&dst2, src); err != nil {
+			t.Fatal(err)
+		}
+		if dst2 != src {
+			t.Error("non-addressable POD source copied wrong")
+		}
+
+		arr := [3]podParticle{src, src, src}
+		var dstArr [3]podParticle
+		if err := c.Copy(&dstArr, arr); err != nil {
+			t.Fatal(err)
+		}
+		if dstArr != arr {
+			t.Error("POD array copied wrong")
+		}
+	})
+
+	t.Run("pod_field_inside_non_pod_struct", func(t *testing.T) {
+		type Body struct {
+			Name string
+			P    podParticle
+			Tags []string
+		}
+		src := &Body{Name: "b", P: podParticle{ID: 9}, Tags: []string{"x"}}
+		src.P.Hist[3] = 42
+		var dst Body
+		if err := New().Copy(&dst, src); err != nil {
+			t.Fatal(err)
+		}
+		if dst.P != src.P || dst.Name != "b" || dst.Tags[0] != "x" {
+			t.Errorf("got %+v", dst)
+		}
+	})
+
+	t.Run("unexported_respects_options", func(t *testing.T) {
+		type Mixed struct {
+			A int
+			b int
+		}
+		src := &Mixed{A: 1, b: 2}
+
+		var dst Mixed
+		if err := New().Copy(&dst, src); err != nil {
+			t.Fatal(err)
+		}
+		if dst.A != 1 || dst.b != 0 {
+			t.Errorf("default mode: got %+v, unexported must stay zero", dst)
+		}
+
+		var dst2 Mixed
+		if err := New().SetCopyUnexported(true).Copy(&dst2, src); err != nil {
+			t.Fatal(err)
+		}
+		if dst2 != *src {
+			t.Errorf("copyUnexported: got %+v", dst2)
+		}
+
+		// 切片元素同理：默认模式下不能整块拷贝
+		srcSlice := []Mixed{{1, 2}}
+		var dstSlice []Mixed
+		if err := New().Copy(&dstSlice, srcSlice); err != nil {
+			t.Fatal(err)
+		}
+		if dstSlice[0].b != 0 {
+			t.Error("slice fast path copied unexported field")
+		}
+	})
+
+	t.Run("zero_allocations", func(t *testing.T) {
+		c := New()
+		src := &podParticle{ID: 1}
+		var dst podParticle
+		c.Copy(&dst, src) // 预热
+
+		allocs := testing.AllocsPerRun(1000, func() {
+			c.Copy(&dst, src)
+		})
+		if allocs != 0 {
+			t.Errorf("POD struct copy allocates: %v", allocs)
+		}
+
+		srcArr := &[64]podVec{}
+		var dstArr [64]podVec
+		c.Copy(&dstArr, srcArr)
+		allocs = testing.AllocsPerRun(1000, func() {
+			c.Copy(&dstArr, srcArr)
+		})
+		if allocs != 0 {
+			t.Errorf("POD array copy allocates: %v", allocs)
+		}
+	})
+}
+
+func BenchmarkCopyPODStruct(b *testing.B) {
+	c := New()
+	src := &podParticle{ID: 1, Pos: podVec{1, 2, 3}}
+	var dst podParticle
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Copy(&dst, src)
+	}
+}
+
+func BenchmarkCopyPODArray(b *testing.B) {
+	c := New()
+	src := new([1024]podParticle)
+	dst := new([1024]podParticle)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(unsafe.Sizeof(*src)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Copy(dst, src)
+	}
+}
+
+// ============================================================================
+// Map 迭代与快速路径测试
+// ============================================================================
+
+func TestMapRangeCopy(t *testing.T) {
+	c := New()
+
+	t.Run("nan_keys_preserved", func(t *testing.T) {
+		src := map[float64]string{1: "one", math.Inf(1): "inf"}
+		src[math.NaN()] = "nan1"
+		src[math.NaN()] = "nan2"
+
+		var dst map[float64]string
+		if err := c.Copy(&dst, src); err != nil {
+			t.Fatal(err)
+		}
+		if len(dst) != len(src) {
+			t.Fatalf("len = %d, want %d", len(dst), len(src))
+		}
+		nanVals := map[string]bool{}
+		for k, v := range dst {
+			if math.IsNaN(k) {
+				nanVals[v] = true
+			}
+		}
+		if !nanVals["nan1"] || !nanVals["nan2"] {
+			t.Errorf("NaN entries lost: %v", nanVals)
+		}
+	})
+
+	t.Run("nan_keys_non_flat_values", func(t *testing.T) {
+		src := map[float64][]int{math.NaN(): {1}, 2: {2}}
+		src[math.NaN()] = []int{3}
+
+		var dst map[float64][]int
+		if err := c.Copy(&dst, src); err != nil {
+			t.Fatal(err)
+		}
+		if len(dst) != 3 {
+			t.Fatalf("len = %d, want 3", len(dst))
+		}
+		for k, v := range dst {
+			if math.IsNaN(k) && v == nil {
+				t.Error("NaN entry lost its value")
+			}
+		}
+	})
+
+	t.Run("flat_fast_path", func(t *testing.T) {
+		if !c.getTypeCopier(reflect.TypeOf(map[string]int{})).isPOD {
+			t.Error("map[string]int should use the clone fast path")
+		}
+		type K struct {
+			A int
+			B string
+		}
+		if !c.getTypeCopier(reflect.TypeOf(map[K][2]float64{})).isPOD {
+			t.Error("map with flat struct key should use the clone fast path")
+		}
+		if c.getTypeCopier(reflect.TypeOf(map[string][]int{})).isPOD {
+			t.Error("map with slice values must not use the clone fast path")
+		}
+
+		src := map[K][2]float64{{1, "a"}: {1, 2}, {2, "b"}: {3, 4}}
+		var dst map[K][2]float64
+		if err := c.Copy(&dst, src); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(dst, src) {
+			t.Fatalf("got %v", dst)
+		}
+		dst[K{1, "a"}] = [2]float64{9, 9}
+		if src[K{1, "a"}][0] != 1 {
+			t.Error("fast path shares storage with src")
+		}
+	})
+
+	t.Run("unexported_map_field_falls_back", func(t *testing.T) {
+		type Holder struct {
+			m map[string]int
+		}
+		src := &Holder{m: map[string]int{"a": 1}}
+		var dst Holder
+		if err := New().SetCopyUnexported(true).Copy(&dst, src); err != nil {
+			t.Fatal(err)
+		}
+		if dst.m["a"] != 1 {
+			t.Fatal("unexported map not copied")
+		}
+		dst.m["a"] = 2
+		if src.m["a"] != 1 {
+			t.Error("unexported map shared with src")
+		}
+	})
+
+	t.Run("shared_map_identity", func(t *testing.T) {
+		type Pair struct {
+			A, B map[string]int
+		}
+		m := map[string]int{"x": 1}
+		src := &Pair{A: m, B: m}
+		var dst Pair
+		if err := c.Copy(&dst, src); err != nil {
+			t.Fatal(err)
+		}
+		dst.A["x"] = 2
+		if dst.B["x"] != 2 {
+			t.Error("shared map identity not preserved")
+		}
+	})
+}
+
+func BenchmarkCopyMapFlat(b *testing.B) {
+	c := New()
+	src := make(map[string]int, 1000)
+	for i := 0; i < 1000; i++ {
+		src[fmt.Sprint(i)] = i
+	}
+	var dst map[string]int
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Copy(&dst, src)
+	}
+}
+
+func BenchmarkCopyMapNonFlat(b *testing.B) {
+	c := New()
+	src := make(map[int][]int, 1000)
+	for i := 0; i < 1000; i++ {
+		src[i] = []int{i}
+	}
+	var dst map[int][]int
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Copy(&dst, src)
+	}
+}
+
+// ============================================================================
+// 无环类型分析测试
+// ============================================================================
+
+func TestIdentityAnalysis(t *testing.T) {
+	c := New()
+	needsVisit := func(v interface{}) bool {
+		return c.getTypeCopier(reflect.TypeOf(v)).needsVisit
+	}
+
+	type Meta struct {
+		Owner string
+		Tags  []string
+	}
+	type ValueTree struct {
+		Name     string
+		Children []ValueTree // 只经由切片递归：值树，不可能共享
+	}
+	type WithLeafPtr struct {
+		Name string
+		Meta *Meta // 只出现一次的指针
+		Opts map[string]int
+	}
+	type TwoPtrs struct {
+		A, B *int // 同类型指针出现两次，可能共享
+	}
+	type LinkedNode struct {
+		Next *LinkedNode
+	}
+	type PtrSlice struct {
+		Items []*Meta // 切片中的指针可能互相共享
+	}
+	type WithAny struct {
+		V interface{}
+	}
+
+	tests := []struct {
+		name string
+		v    interface{}
+		want bool
+	}{
+		{"scalar", 1, false},
+		{"string_slice", []string{}, false},
+		{"value_tree", ValueTree{}, false},
+		{"leaf_pointer_once", WithLeafPtr{}, false},
+		{"pointer_to_acyclic_struct", &WithLeafPtr{}, false},
+		{"two_same_type_pointers", TwoPtrs{}, true},
+		{"recursive_pointer", LinkedNode{}, true},
+		{"pointers_in_slice", PtrSlice{}, true},
+		{"map_of_pointers", map[string]*Meta{}, true},
+		{"interface", WithAny{}, true},
+		{"array_of_two_pointers", [2]*int{}, true},
+		{"array_of_one_pointer", [1]*int{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := needsVisit(tt.v); got != tt.want {
+				t.Errorf("needsVisit = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("sharing_still_preserved_when_possible", func(t *testing.T) {
+		x := 1
+		src := &TwoPtrs{A: &x, B: &x}
+		var dst TwoPtrs
+		if err := c.Copy(&dst, src); err != nil {
+			t.Fatal(err)
+		}
+		if dst.A != dst.B || dst.A == &x {
+			t.Error("shared pointer identity not preserved")
+		}
+	})
+
+	t.Run("acyclic_copy_correct", func(t *testing.T) {
+		src := &WithLeafPtr{Name: "n", Meta: &Meta{Owner: "o", Tags: []string{"t"}}, Opts: map[string]int{"a": 1}}
+		var dst WithLeafPtr
+		if err := c.Copy(&dst, src); err != nil {
+			t.Fatal(err)
+		}
+		if dst.Meta == src.Meta || dst.Meta.Owner != "o" || dst.Opts["a"] != 1 {
+			t.Errorf("got %+v", dst)
+		}
+
+		tree := ValueTree{Name: "root", Children: []ValueTree{{Name: "a", Children: []ValueTree{{Name: "b"}}}}}
+		var dstTree ValueTree
+		if err := c.Copy(&dstTree, tree); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(dstTree, tree) {
+			t.Error("value tree copied wrong")
+		}
+	})
+}
+
+func BenchmarkCopyAcyclic(b *testing.B) {
+	type Meta struct {
+		Owner string
+		Tags  []string
+	}
+	type Doc struct {
+		Title string
+		Meta  *Meta
+		Lines []string
+	}
+	c := New()
+	src := &Doc{Title: "t", Meta: &Meta{Owner: "o", Tags: []string{"a", "b"}}, Lines: []string{"1", "2", "3"}}
+	var dst Doc
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Copy(&dst, src)
+	}
+}
+
+// ============================================================================
+// 身份表测试
+// ============================================================================
+
+func TestVisitTable(t *testing.T) {
+	t.Run("insert_lookup_grow", func(t *testing.T) {
+		tbl := acquireVisitTable()
+		defer releaseVisitTable(tbl)
+
+		typA := rtypeOf(reflect.TypeOf(0))
+		typB := rtypeOf(reflect.TypeOf(""))
+		targets := make([]int, 10000)
+		for i := range targets {
+			tbl.insert(uintptr(0x1000+i*8), typA, unsafe.Pointer(&targets[i]))
+		}
+		if tbl.count != len(targets) {
+			t.Fatalf("count = %d", tbl.count)
+		}
+		for i := range targets {
+			p, ok := tbl.lookup(uintptr(0x1000+i*8), typA)
+			if !ok || p != unsafe.Pointer(&targets[i]) {
+				t.Fatalf("lookup %d failed", i)
+			}
+			// 同地址不同类型是不同的 key
+			if _, ok := tbl.lookup(uintptr(0x1000+i*8), typB); ok {
+				t.Fatalf("type not part of key at %d", i)
+			}
+		}
+	})
+
+	t.Run("released_table_is_empty", func(t *testing.T) {
+		tbl := acquireVisitTable()
+		x := 1
+		tbl.insert(0x1234, rtypeOf(reflect.TypeOf(0)), unsafe.Pointer(&x))
+		releaseVisitTable(tbl)
+		if tbl.count != 0 {
+			t.Error("count not reset")
+		}
+		for _, s := range tbl.slots {
+			if s.src != 0 || s.dst != nil {
+				t.Fatal("slot not cleared on release")
+			}
+		}
+	})
+
+	t.Run("release_clears_only_used_slots", func(t *testing.T) {
+		typ := rtypeOf(reflect.TypeOf(0))
+		x := 1
+
+		// 池中取来的大数组只被稀疏使用：记录占用的槽位，归还时只清这些槽位
+		const n = 20000
+		tbl := &visitTable{}
+		tbl.growLarge(1 << 20)
+		for i := 0; i < n; i++ {
+			tbl.insert(uintptr(0x1000+i*8), typ, unsafe.Pointer(&x))
+		}
+		if len(tbl.slots) != 1<<20 || !tbl.logged || len(tbl.used) != n {
+			t.Fatalf("large table not tracking used slots: %d slots, logged=%v, used=%d", len(tbl.slots), tbl.logged, len(tbl.used))
+		}
+		for _, s := range tbl.detachLarge().slots {
+			if s.src != 0 || s.dst != nil {
+				t.Fatal("slot not cleared on release")
+			}
+		}
+
+		// 由小表逐级扩容而来的大表登记项超过容量的 1/8，改为整表清零
+		dense := &visitTable{}
+		dense.init(visitTableMinSize)
+		for i := 0; i < n; i++ {
+			dense.insert(uintptr(0x1000+i*8), typ, unsafe.Pointer(&x))
+		}
+		if len(dense.slots) <= visitTableLargeSize || dense.logged {
+			t.Fatalf("dense table: %d slots, logged=%v", len(dense.slots), dense.logged)
+		}
+		for _, s := range dense.detachLarge().slots {
+			if s.src != 0 {
+				t.Fatal("dense table not cleared")
+			}
+		}
+
+		// 小表中登记项远少于容量时换成小表
+		small := &visitTable{}
+		small.init(visitTableLargeSize)
+		small.insert(0x1234, typ, unsafe.Pointer(&x))
+		releaseVisitTable(small)
+		if len(small.slots) != visitTableMinSize || small.count != 0 {
+			t.Errorf("sparse table not shrunk: %d slots, count %d", len(small.slots), small.count)
+		}
+	})
+
+	t.Run("large_cyclic_graph", func(t *testing.T) {
+		type Node struct {
+			ID   int
+			Next *Node
+			Back *Node
+		}
+		const n = 100000
+		nodes := make([]*Node, n)
+		for i := range nodes {
+			nodes[i] = &Node{ID: i}
+		}
+		for i := range nodes {
+			nodes[i].Next = nodes[(i+1)%n]
+			nodes[i].Back = nodes[(i+n-1)%n]
+		}
+
+		dstVal, err := New().Clone(nodes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := dstVal.([]*Node)
+		for i := range dst {
+			if dst[i] == nodes[i] || dst[i].ID != i {
+				t.Fatalf("node %d not copied", i)
+			}
+			if dst[i].Next != dst[(i+1)%n] || dst[i].Back != dst[(i+n-1)%n] {
+				t.Fatalf("node %d links not remapped", i)
+			}
+		}
+	})
+}
+
+func BenchmarkCopyLargeCyclicGraph(b *testing.B) {
+	type Node struct {
+		ID    int
+		Edges []*Node
+	}
+	const n = 10000
+	nodes := make([]*Node, n)
+	for i := range nodes {
+		nodes[i] = &Node{ID: i}
+	}
+	for i, nd := range nodes {
+		nd.Edges = []*Node{nodes[(i+1)%n], nodes[(i*7)%n], nodes[(i+n-1)%n]}
+	}
+	c := New()
+	var dst []*Node
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Copy(&dst, nodes)
+	}
+}
+
+// ============================================================================
+// 接口内联缓存测试
+// ============================================================================
+
+func TestInterfaceInlineCache(t *testing.T) {
+	type Item struct {
+		N int
+		S []string
+	}
+
+	t.Run("monomorphic", func(t *testing.T) {
+		c := NewHighVolume().SetSharedPlans(false)
+		src := []interface{}{Item{N: 1}, Item{N: 2}, Item{N: 3}}
+		var dst []interface{}
+		if err := c.Copy(&dst, src); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(dst, src) {
+			t.Fatalf("got %v", dst)
+		}
+
+		ifaceTC := c.getTypeCopier(reflect.TypeOf(src)).elem
+		ic := ifaceTC.ic.Load()
+		if ic == nil || ic.n != 1 {
+			t.Fatalf("inline cache not populated: %+v", ic)
+		}
+		if ic.entries[0].tc != c.getTypeCopier(reflect.TypeOf(Item{})) {
+			t.Error("cached plan differs from global plan")
+		}
+	})
+
+	t.Run("polymorphic_and_megamorphic", func(t *testing.T) {
+		c := New().SetSharedPlans(false)
+		type T0 struct{ V int }
+		type T1 struct{ V int }
+		src := []interface{}{
+			1, "s", 1.5, true, int8(1), int16(1), int32(1), int64(1),
+			uint(1), uint8(1), T0{1}, T1{2}, &T0{3},
+		}
+		for round := 0; round < 2; round++ {
+			var dst []interface{}
+			if err := c.Copy(&dst, src); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(dst, src) {
+				t.Fatalf("round %d: got %v", round, dst)
+			}
+		}
+		ic := c.getTypeCopier(reflect.TypeOf(src)).elem.ic.Load()
+		if ic == nil || ic.n != ifaceCacheSize {
+			t.Errorf("expected a full cache, got %+v", ic)
+		}
+	})
+
+	t.Run("concurrent_population", func(t *testing.T) {
+		c := New().SetSharedPlans(false)
+		type A struct{ X int }
+		type B struct{ Y string }
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				src := map[string]interface{}{"a": A{i}, "b": B{"y"}, "n": i}
+				var dst map[string]interface{}
+				if err := c.Copy(&dst, src); err != nil {
+					t.Error(err)
+					return
+				}
+				if dst["a"].(A).X != i || dst["n"] != i {
+					t.Errorf("got %v", dst)
+				}
+			}(i)
+		}
+		wg.Wait()
+	})
+}
+
+func BenchmarkCopyInterfaceSlice(b *testing.B) {
+	type Item struct {
+		N int
+		S string
+	}
+	src := make([]interface{}, 1000)
+	for i := range src {
+		src[i] = Item{N: i, S: "x"}
+	}
+	for _, mode := range []struct {
+		name string
+		c    *Copier
+	}{{"COW", New()}, {"HighVolume", NewHighVolume()}} {
+		b.Run(mode.name, func(b *testing.B) {
+			var dst []interface{}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				mode.c.Copy(&dst, src)
+			}
+		})
+	}
+}
+
+// ============================================================================
+// JSON 树快速路径测试
+// ============================================================================
+
+const jsonSample = `{
+	"id": 12345, "name": "widget", "active": true, "price": 19.99, "note": null,
+	"tags": ["a", "b", "c"],
+	"dims": {"w": 1.5, "h": 2.5, "d": [1, 2, {"deep": "yes"}]},
+	"items": [
+		{"sku": "x1", "qty": 2, "opts": {"color": "red"}},
+		{"sku": "x2", "qty": 1, "opts": {"color": "blue", "size": [1, 2]}}
+	]
+}`
+
+func decodeJSONSample(tb testing.TB, useNumber bool) map[string]any {
+	dec := json.NewDecoder(strings.NewReader(jsonSample))
+	if useNumber {
+		dec.UseNumber()
+	}
+	var m map[string]any
+	if err := dec.Decode(&m); err != nil {
+		tb.Fatal(err)
+	}
+	return m
+}
+
+func TestJSONFastPath(t *testing.T) {
+	c := New()
+
+	t.Run("plans_flagged", func(t *testing.T) {
+		if !c.getTypeCopier(reflect.TypeOf(map[string]any{})).json {
+			t.Error("map[string]any not flagged")
+		}
+		if !c.getTypeCopier(reflect.TypeOf([]any{})).json {
+			t.Error("[]any not flagged")
+		}
+		type Named map[string]any
+		if c.getTypeCopier(reflect.TypeOf(Named{})).json {
+			t.Error("named map type should use the generic path")
+		}
+	})
+
+	t.Run("named_tree_generic", func(t *testing.T) {
+		// 基准 BenchmarkCopyJSONTreeGeneric 的对照树：结果与快速路径一致
+		src := namedJSONTree(decodeJSONSample(t, false)).(jsonObj)
+		var dst jsonObj
+		if err := c.Copy(&dst, src); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(dst, src) {
+			t.Fatalf("got %v", dst)
+		}
+		if _, ok := dst["dims"].(jsonObj)["d"].(jsonArr); !ok {
+			t.Error("nested named containers not preserved")
+		}
+	})
+
+	for _, useNumber := range []bool{false, true} {
+		t.Run(fmt.Sprintf("deep_equal_number_%v", useNumber), func(t *testing.T) {
+			src := decodeJSONSample(t, useNumber)
+			var dst map[string]any
+			if err := c.Copy(&dst, src); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(dst, src) {
+				t.Fatalf("got %v", dst)
+			}
+
+			// 独立性：逐层修改不影响 src
+			dst["dims"].(map[string]any)["d"].([]any)[2].(map[string]any)["deep"] = "no"
+			dst["tags"].([]any)[0] = "z"
+			if src["dims"].(map[string]any)["d"].([]any)[2].(map[string]any)["deep"] != "yes" {
+				t.Error("nested object shared with src")
+			}
+			if src["tags"].([]any)[0] != "a" {
+				t.Error("array shared with src")
+			}
+		})
+	}
+
+	t.Run("through_interface", func(t *testing.T) {
+		var src any = []any{map[string]any{"k": []any{1.0, "s"}}}
+		dst, err := c.Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(dst, src) {
+			t.Fatalf("got %v", dst)
+		}
+		dst.([]any)[0].(map[string]any)["k"] = nil
+		if src.([]any)[0].(map[string]any)["k"] == nil {
+			t.Error("interface-wrapped tree shared with src")
+		}
+	})
+
+	t.Run("foreign_values_fall_back", func(t *testing.T) {
+		type Point struct{ X, Y *int }
+		x := 1
+		src := map[string]any{"p": &Point{X: &x}, "n": []int{1, 2}, "nil_map": map[string]any(nil)}
+		var dst map[string]any
+		if err := c.Copy(&dst, src); err != nil {
+			t.Fatal(err)
+		}
+		p := dst["p"].(*Point)
+		if p == src["p"] || p.X == &x || *p.X != 1 {
+			t.Error("non-JSON value not deep copied")
+		}
+		if m, ok := dst["nil_map"].(map[string]any); !ok || m != nil {
+			t.Error("typed nil map not preserved")
+		}
+	})
+
+	t.Run("cycles_and_sharing", func(t *testing.T) {
+		shared := map[string]any{"v": 1.0}
+		src := map[string]any{"a": shared, "b": shared}
+		src["self"] = src
+
+		var dst map[string]any
+		if err := c.Copy(&dst, src); err != nil {
+			t.Fatal(err)
+		}
+		self := dst["self"].(map[string]any)
+		self["marker"] = true
+		if dst["marker"] != true {
+			t.Error("self cycle not preserved")
+		}
+		dst["a"].(map[string]any)["v"] = 2.0
+		if dst["b"].(map[string]any)["v"] != 2.0 {
+			t.Error("shared object not preserved")
+		}
+		if shared["v"] != 1.0 {
+			t.Error("src modified")
+		}
+	})
+}
+
+func BenchmarkCopyJSONTree(b *testing.B) {
+	src := decodeJSONSample(b, false)
+	c := New()
+	var dst map[string]any
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Copy(&dst, src)
+	}
+}
+
+// jsonObj、jsonArr 与 JSON 树结构相同的具名类型，不在快速路径的闭集内
+type (
+	jsonObj map[string]any
+	jsonArr []any
+)
+
+// namedJSONTree 把 JSON 树的每一层容器换成具名类型，整棵树都走通用路径
+func namedJSONTree(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		m := make(jsonObj, len(x))
+		for k, e := range x {
+			m[k] = namedJSONTree(e)
+		}
+		return m
+	case []any:
+		a := make(jsonArr, len(x))
+		for i, e := range x {
+			a[i] = namedJSONTree(e)
+		}
+		return a
+	}
+	return v
+}
+
+func BenchmarkCopyJSONTreeGeneric(b *testing.B) {
+	src := namedJSONTree(decodeJSONSample(b, false)).(jsonObj)
+	c := New()
+	var dst jsonObj
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Copy(&dst, src)
+	}
+}
+
+func BenchmarkJSONRoundTrip(b *testing.B) {
+	src := decodeJSONSample(b, false)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		data, _ := json.Marshal(src)
+		var dst map[string]any
+		json.Unmarshal(data, &dst)
+	}
+}
+
+// ============================================================================
+// 并行拷贝测试
+// ============================================================================
+
+type parRecord struct {
+	ID   int
+	Name string
+	Tags []string
+	Next *parRecord
+}
+
+func makeParRecords(n int) []parRecord {
+	recs := make([]parRecord, n)
+	for i := range recs {
+		recs[i] = parRecord{ID: i, Name: fmt.Sprint("r", i), Tags: []string{"a", fmt.Sprint(i)}}
+	}
+	return recs
+}
+
+func TestParallelCopy(t *testing.T) {
+	seq := New()
+	par := New().SetParallel(4, 64)
+
+	t.Run("slice_matches_sequential", func(t *testing.T) {
+		src := makeParRecords(5000)
+		var want, got []parRecord
+		if err := seq.Copy(&want, src); err != nil {
+			t.Fatal(err)
+		}
+		if err := par.Copy(&got, src); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Fatal("parallel result differs from sequential")
+		}
+		got[0].Tags[0] = "changed"
+		if src[0].Tags[0] != "a" {
+			t.Error("src modified")
+		}
+	})
+
+	t.Run("array", func(t *testing.T) {
+		type Arr [1000]*int
+		src := new(Arr)
+		for i := range src {
+			v := i
+			src[i] = &v
+		}
+		got, err := par.Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := got.(*Arr)
+		for i := range dst {
+			if dst[i] == src[i] || *dst[i] != i {
+				t.Fatalf("element %d not deep copied", i)
+			}
+		}
+	})
+
+	t.Run("map", func(t *testing.T) {
+		src := make(map[int]*parRecord, 3000)
+		for i := 0; i < 3000; i++ {
+			src[i] = &parRecord{ID: i, Tags: []string{fmt.Sprint(i)}}
+		}
+		var want, got map[int]*parRecord
+		seq.Copy(&want, src)
+		if err := par.Copy(&got, src); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Fatal("parallel result differs from sequential")
+		}
+		if got[7] == src[7] {
+			t.Error("map value not deep copied")
+		}
+	})
+
+	t.Run("many_maps_same_type", func(t *testing.T) {
+		// 各 worker 同时填充同类型的 map，互不干扰
+		type V struct{ N int }
+		type Holder struct {
+			A map[string]*V
+			B []map[string]*V
+		}
+		src := Holder{A: map[string]*V{"a": {N: -1}}, B: make([]map[string]*V, 20000)}
+		for i := range src.B {
+			src.B[i] = map[string]*V{"k": {N: i}, fmt.Sprint(i): {N: i + 1}}
+		}
+		c := New().SetParallel(8, 64)
+		check := func(got Holder) {
+			for i, m := range got.B {
+				if len(m) != 2 || m["k"].N != i || m[fmt.Sprint(i)].N != i+1 {
+					t.Fatalf("map %d mismatch: %v", i, m)
+				}
+			}
+		}
+		var got Holder
+		if err := c.Copy(&got, src); err != nil {
+			t.Fatal(err)
+		}
+		check(got)
+		// CopyInto 写入已有的 map，会复用 key/value 临时变量
+		if err := c.CopyInto(&got, src); err != nil {
+			t.Fatal(err)
+		}
+		check(got)
+	})
+
+	t.Run("sharing_across_chunks", func(t *testing.T) {
+		// 每个元素指向前一个元素，首元素指回尾元素：相邻引用必然跨越段边界
+		src := make([]*parRecord, 4000)
+		for i := range src {
+			src[i] = &parRecord{ID: i}
+		}
+		for i := 1; i < len(src); i++ {
+			src[i].Next = src[i-1]
+		}
+		src[0].Next = src[len(src)-1]
+
+		var dst []*parRecord
+		if err := par.Copy(&dst, src); err != nil {
+			t.Fatal(err)
+		}
+		for i := 1; i < len(dst); i++ {
+			if dst[i].Next != dst[i-1] {
+				t.Fatalf("link %d not preserved", i)
+			}
+		}
+		if dst[0].Next != dst[len(dst)-1] {
+			t.Error("cycle not preserved")
+		}
+		if dst[0] == src[0] {
+			t.Error("not deep copied")
+		}
+	})
+
+	t.Run("json_array", func(t *testing.T) {
+		obj := map[string]any{"k": "v"}
+		src := make([]any, 2000)
+		for i := range src {
+			src[i] = obj
+		}
+		got, err := par.Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := got.([]any)
+		if reflect.ValueOf(dst[0]).Pointer() != reflect.ValueOf(dst[1999]).Pointer() {
+			t.Error("shared object not preserved across chunks")
+		}
+	})
+
+	t.Run("strict_error_from_worker", func(t *testing.T) {
+		c := New().SetStrict(true).SetParallel(4, 64)
+		src := make([]any, 1000)
+		for i := range src {
+			src[i] = i
+		}
+		src[900] = make(chan int)
+		var dst []any
+		if err := c.Copy(&dst, src); err == nil {
+			t.Error("expected strict error from worker chunk")
+		}
+	})
+
+	t.Run("concurrent_copies", func(t *testing.T) {
+		src := makeParRecords(2000)
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var dst []parRecord
+				if err := par.Copy(&dst, src); err != nil {
+					t.Error(err)
+					return
+				}
+				if !reflect.DeepEqual(src, dst) {
+					t.Error("mismatch")
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("disable", func(t *testing.T) {
+		c := New().SetParallel(4, 0).SetParallel(1, 0)
+		if c.par != nil {
+			t.Error("workers=1 should disable parallel mode")
+		}
+	})
+}
+
+func BenchmarkCopySliceParallel(b *testing.B) {
+	src := makeParRecords(200000)
+	for _, workers := range []int{1, 4, 8} {
+		b.Run(fmt.Sprint("workers=", workers), func(b *testing.B) {
+			c := New().SetParallel(workers, 0)
+			var dst []parRecord
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.Copy(&dst, src)
+			}
+		})
+	}
+}
+
+// ============================================================================
+// 分配器测试
+// ============================================================================
+
+type slabNode struct {
+	Val         int
+	Left, Right *slabNode
+}
+
+func makeSlabTree(depth int) *slabNode {
+	if depth == 0 {
+		return nil
+	}
+	return &slabNode{Val: depth, Left: makeSlabTree(depth - 1), Right: makeSlabTree(depth - 1)}
+}
+
+// countingAllocator 记录每种类型的分配次数，验证可插拔接口
+type countingAllocator struct {
+	counts map[reflect.Type]int
+}
+
+func (a *countingAllocator) New(t reflect.Type) reflect.Value {
+	a.counts[t]++
+	return reflect.New(t)
+}
+
+func TestAllocator(t *testing.T) {
+	t.Run("slab_tree", func(t *testing.T) {
+		src := makeSlabTree(10)
+		c := New().SetAllocator(NewSlabAllocator)
+		got, err := c.Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := got.(*slabNode)
+		if !reflect.DeepEqual(src, dst) {
+			t.Fatal("slab copy differs")
+		}
+		if dst == src || dst.Left == src.Left {
+			t.Error("not deep copied")
+		}
+		// 同一块内依次切出：相邻分配的地址连续
+		size := unsafe.Sizeof(slabNode{})
+		if uintptr(unsafe.Pointer(dst.Left)) != uintptr(unsafe.Pointer(dst))+size {
+			t.Error("expected consecutive slab allocations")
+		}
+	})
+
+	t.Run("cycles_and_sharing", func(t *testing.T) {
+		a := &slabNode{Val: 1}
+		b := &slabNode{Val: 2, Left: a}
+		a.Left, a.Right = b, b
+		c := New().SetAllocator(NewSlabAllocator)
+		got, err := c.Clone(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := got.(*slabNode)
+		if dst.Left != dst.Right || dst.Left.Left != dst {
+			t.Error("graph structure not preserved")
+		}
+	})
+
+	t.Run("custom_allocator", func(t *testing.T) {
+		var last *countingAllocator
+		c := New().SetAllocator(func() Allocator {
+			last = &countingAllocator{counts: make(map[reflect.Type]int)}
+			return last
+		})
+		type Wrap struct {
+			N *int
+			S *string
+		}
+		x, s := 1, "s"
+		if _, err := c.Clone(&Wrap{N: &x, S: &s}); err != nil {
+			t.Fatal(err)
+		}
+		if last.counts[reflect.TypeOf(0)] != 1 || last.counts[reflect.TypeOf("")] != 1 ||
+			last.counts[reflect.TypeOf(Wrap{})] != 1 {
+			t.Errorf("unexpected allocations: %v", last.counts)
+		}
+	})
+
+	t.Run("parallel", func(t *testing.T) {
+		src := make([]*slabNode, 3000)
+		for i := range src {
+			src[i] = &slabNode{Val: i}
+		}
+		c := New().SetAllocator(NewSlabAllocator).SetParallel(4, 64)
+		var dst []*slabNode
+		if err := c.Copy(&dst, src); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(src, dst) {
+			t.Error("parallel slab copy differs")
+		}
+	})
+
+	t.Run("zero_size", func(t *testing.T) {
+		type Empty struct{}
+		src := []*Empty{{}, {}}
+		c := New().SetAllocator(NewSlabAllocator)
+		var dst []*Empty
+		if err := c.Copy(&dst, src); err != nil || len(dst) != 2 || dst[0] == nil {
+			t.Errorf("zero-size copy failed: %v", err)
+		}
+	})
+}
+
+func BenchmarkCopyTreeSlab(b *testing.B) {
+	src := makeSlabTree(16)
+	for _, tc := range []struct {
+		name string
+		c    *Copier
+	}{
+		{"heap", New()},
+		{"slab", New().SetAllocator(NewSlabAllocator)},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				tc.c.Clone(src)
+			}
+		})
+	}
+}
+
+// ============================================================================
+// CopyInto 测试
+// ============================================================================
+
+type frameEntity struct {
+	ID    int
+	Pos   [3]float64
+	Tags  []string
+	Attrs map[string]int
+	Child *frameEntity
+	Extra any
+}
+
+type frameState struct {
+	Tick     int
+	Entities []frameEntity
+	ByName   map[string]*frameEntity
+	Focus    *frameEntity
+}
+
+func makeFrameState(tick int) *frameState {
+	s := &frameState{Tick: tick, ByName: make(map[string]*frameEntity)}
+	for i := 0; i < 50; i++ {
+		e := frameEntity{
+			ID:    i,
+			Pos:   [3]float64{float64(tick), float64(i), 0},
+			Tags:  []string{"t", fmt.Sprint(i)},
+			Attrs: map[string]int{"hp": tick + i},
+			Child: &frameEntity{ID: -i},
+			Extra: &frameEntity{ID: 1000 + i},
+		}
+		s.Entities = append(s.Entities, e)
+	}
+	s.ByName["boss"] = &frameEntity{ID: 99, Tags: []string{"boss"}}
+	s.Focus = s.ByName["boss"]
+	return s
+}
+
+func TestCopyInto(t *testing.T) {
+	c := New()
+
+	t.Run("matches_copy", func(t *testing.T) {
+		src := makeFrameState(1)
+		var want, got frameState
+		c.Copy(&want, src)
+		if err := c.CopyInto(&got, src); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Fatal("CopyInto into zero value differs from Copy")
+		}
+
+		// 再拷一次不同的数据进已有缓冲区
+		src2 := makeFrameState(2)
+		src2.Entities = src2.Entities[:30]
+		delete(src2.ByName, "boss")
+		src2.Focus = nil
+		c.Copy(&want, src2)
+		if err := c.CopyInto(&got, src2); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Fatal("CopyInto into existing buffer differs from Copy")
+		}
+	})
+
+	t.Run("reuses_allocations", func(t *testing.T) {
+		var dst frameState
+		c.CopyInto(&dst, makeFrameState(1))
+		ents := dst.Entities
+		child := dst.Entities[3].Child
+		extra := dst.Entities[3].Extra
+		attrs := dst.Entities[3].Attrs
+		byName := dst.ByName
+
+		src := makeFrameState(2)
+		if err := c.CopyInto(&dst, src); err != nil {
+			t.Fatal(err)
+		}
+		if &dst.Entities[0] != &ents[0] {
+			t.Error("slice backing array not reused")
+		}
+		if dst.Entities[3].Child != child || dst.Entities[3].Extra != extra {
+			t.Error("pointer target not reused")
+		}
+		if reflect.ValueOf(dst.Entities[3].Attrs).Pointer() != reflect.ValueOf(attrs).Pointer() ||
+			reflect.ValueOf(dst.ByName).Pointer() != reflect.ValueOf(byName).Pointer() {
+			t.Error("map not reused")
+		}
+		if dst.Entities[3].Attrs["hp"] != 5 || dst.Entities[3].Pos[0] != 2 {
+			t.Error("values not updated")
+		}
+		if dst.Entities[3].Child == src.Entities[3].Child {
+			t.Error("not deep copied")
+		}
+	})
+
+	t.Run("steady_state_allocs", func(t *testing.T) {
+		type Frame struct {
+			Pos   []float64
+			Names []string
+			Items []*frameEntity
+			Index map[int]int
+		}
+		src := &Frame{Index: map[int]int{}}
+		for i := 0; i < 100; i++ {
+			src.Pos = append(src.Pos, float64(i))
+			src.Names = append(src.Names, fmt.Sprint(i))
+			src.Items = append(src.Items, &frameEntity{ID: i, Pos: [3]float64{1, 2, 3}})
+			src.Index[i] = i
+		}
+		c := New().SetHandleCycle(false)
+		var dst Frame
+		c.CopyInto(&dst, src)
+		allocs := testing.AllocsPerRun(20, func() {
+			c.CopyInto(&dst, src)
+		})
+		// map 填充需要两个临时变量，其余全部复用
+		if allocs > 4 {
+			t.Errorf("steady-state CopyInto allocs = %v", allocs)
+		}
+	})
+
+	t.Run("dst_sharing_not_clobbered", func(t *testing.T) {
+		type Pair struct{ A, B *frameEntity }
+		shared := &frameEntity{ID: 0}
+		dst := Pair{A: shared, B: shared}
+		src := &Pair{A: &frameEntity{ID: 1}, B: &frameEntity{ID: 2}}
+		if err := c.CopyInto(&dst, src); err != nil {
+			t.Fatal(err)
+		}
+		if dst.A.ID != 1 || dst.B.ID != 2 {
+			t.Errorf("got A=%d B=%d", dst.A.ID, dst.B.ID)
+		}
+	})
+
+	t.Run("cycle_to_root", func(t *testing.T) {
+		src := &frameEntity{ID: 1}
+		src.Child = src
+		dst := &frameEntity{}
+		if err := c.CopyInto(dst, src); err != nil {
+			t.Fatal(err)
+		}
+		if dst.Child != dst {
+			t.Error("cycle to root should point at dst")
+		}
+	})
+
+	t.Run("aliasing_src", func(t *testing.T) {
+		// dst 指向 src 的对象时不能原地写入，否则拷贝与源共享
+		inner := &frameEntity{ID: 1}
+		src := &frameEntity{Child: inner}
+		dst := &frameEntity{Child: inner}
+		c.CopyInto(dst, src)
+		if dst.Child == inner {
+			t.Error("dst must not alias src after CopyInto")
+		}
+	})
+
+	t.Run("interface_change_type", func(t *testing.T) {
+		dst := frameEntity{Extra: &frameEntity{ID: 1}}
+		src := &frameEntity{Extra: "text"}
+		c.CopyInto(&dst, src)
+		if dst.Extra != "text" {
+			t.Errorf("Extra = %v", dst.Extra)
+		}
+	})
+
+	t.Run("parallel", func(t *testing.T) {
+		cp := New().SetParallel(4, 8)
+		var dst frameState
+		for tick := 0; tick < 3; tick++ {
+			src := makeFrameState(tick)
+			if err := cp.CopyInto(&dst, src); err != nil {
+				t.Fatal(err)
+			}
+			var want frameState
+			c.Copy(&want, src)
+			if !reflect.DeepEqual(want, dst) {
+				t.Fatalf("tick %d: mismatch", tick)
+			}
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var x int
+		if err := c.CopyInto(x, 1); err == nil {
+			t.Error("expected error for non-pointer dst")
+		}
+		var s string
+		if err := c.CopyInto(&s, 1); err == nil {
+			t.Error("expected type mismatch")
+		}
+	})
+}
+
+func BenchmarkCopyInto(b *testing.B) {
+	src := makeFrameState(1)
+	b.Run("Copy", func(b *testing.B) {
+		c := New()
+		var dst frameState
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			c.Copy(&dst, src)
+		}
+	})
+	b.Run("CopyInto", func(b *testing.B) {
+		c := New()
+		var dst frameState
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			c.CopyInto(&dst, src)
+		}
+	})
+}
+
+// ============================================================================
+// Restore 测试
+// ============================================================================
+
+type worldNode struct {
+	Name     string
+	HP       int
+	Children []*worldNode
+	Parent   *worldNode
+	Props    map[string]*worldNode
+	Meta     any
+}
+
+type world struct {
+	Root  *worldNode
+	Index map[string]*worldNode
+	Order []*worldNode
+}
+
+func makeWorld() *world {
+	root := &worldNode{Name: "root", HP: 100, Props: map[string]*worldNode{}}
+	w := &world{Root: root, Index: map[string]*worldNode{"root": root}}
+	for i := 0; i < 3; i++ {
+		n := &worldNode{Name: fmt.Sprint("n", i), HP: i, Parent: root, Meta: &worldNode{Name: "meta"}}
+		root.Children = append(root.Children, n)
+		root.Props[n.Name] = n
+		w.Index[n.Name] = n
+		w.Order = append(w.Order, n)
+	}
+	return w
+}
+
+func TestRestore(t *testing.T) {
+	c := New()
+
+	t.Run("preserves_identity", func(t *testing.T) {
+		live := makeWorld()
+		snap, err := c.Clone(live)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// 外部持有的引用
+		held := live.Index["n1"]
+		heldMeta := held.Meta.(*worldNode)
+		heldRoot := live.Root
+
+		held.HP = 999
+		heldMeta.Name = "changed"
+		live.Root.Name = "renamed"
+
+		if err := c.Restore(live, snap); err != nil {
+			t.Fatal(err)
+		}
+		if live.Index["n1"] != held || live.Root != heldRoot || live.Root.Children[1] != held {
+			t.Fatal("identity not preserved")
+		}
+		if held.HP != 1 || heldMeta.Name != "meta" || heldRoot.Name != "root" {
+			t.Errorf("state not rolled back: hp=%d meta=%q root=%q", held.HP, heldMeta.Name, heldRoot.Name)
+		}
+		if held.Parent != heldRoot || live.Order[1] != held || live.Root.Props["n1"] != held {
+			t.Error("sharing not preserved")
+		}
+		if !reflect.DeepEqual(live, snap) {
+			t.Error("restored graph differs from snapshot")
+		}
+	})
+
+	t.Run("shared_map_inside_values", func(t *testing.T) {
+		type Entry struct{ M map[string]int }
+		shared := map[string]int{"v": 1}
+		snap := map[string]Entry{"a": {M: shared}, "b": {M: shared}}
+		live := map[string]Entry{"a": {M: map[string]int{"v": 100}}, "b": {M: map[string]int{"v": 200}}}
+
+		if err := c.Restore(&live, snap); err != nil {
+			t.Fatal(err)
+		}
+		if live["a"].M["v"] != 1 || live["b"].M["v"] != 1 {
+			t.Fatalf("maps not rolled back: a=%v b=%v", live["a"].M, live["b"].M)
+		}
+		live["a"].M["v"] = 2
+		if live["b"].M["v"] != 2 || shared["v"] != 1 {
+			t.Error("sharing between values not preserved")
+		}
+	})
+
+	t.Run("added_and_removed_nodes", func(t *testing.T) {
+		live := makeWorld()
+		snap, _ := c.Clone(live)
+		held := live.Index["n0"]
+
+		// 快照之后新增节点、删除节点
+		extra := &worldNode{Name: "extra"}
+		live.Root.Children = append(live.Root.Children, extra)
+		live.Index["extra"] = extra
+		live.Root.Props["extra"] = extra
+		delete(live.Index, "n2")
+		live.Order = live.Order[:1]
+
+		if err := c.Restore(live, snap); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := live.Index["extra"]; ok {
+			t.Error("key added after snapshot should be removed")
+		}
+		if len(live.Root.Children) != 3 || len(live.Order) != 3 {
+			t.Errorf("lengths not restored: children=%d order=%d", len(live.Root.Children), len(live.Order))
+		}
+		if live.Index["n2"] == nil || live.Index["n2"] != live.Root.Children[2] {
+			t.Error("removed node not restored consistently")
+		}
+		if live.Index["n0"] != held {
+			t.Error("identity of surviving node lost")
+		}
+		if !reflect.DeepEqual(live, snap) {
+			t.Error("restored graph differs from snapshot")
+		}
+	})
+
+	t.Run("map_value_identity", func(t *testing.T) {
+		type Reg struct{ M map[string]*worldNode }
+		live := &Reg{M: map[string]*worldNode{"a": {HP: 1}, "b": {HP: 2}}}
+		snap, _ := c.Clone(live)
+		a := live.M["a"]
+		a.HP = 10
+		live.M["a"] = &worldNode{HP: 11} // 替换条目：恢复写入新的值对象，外部持有的 a 不受影响
+		live.M["b"].HP = 20
+		b := live.M["b"]
+
+		c.Restore(live, snap)
+		if live.M["b"] != b || b.HP != 2 {
+			t.Error("map value not restored in place")
+		}
+		if live.M["a"].HP != 1 {
+			t.Error("replaced entry not restored")
+		}
+	})
+
+	t.Run("nan_keys", func(t *testing.T) {
+		nan := math.NaN()
+		live := map[float64]int{1: 1, nan: 2}
+		snap := map[float64]int{1: 1, nan: 3}
+		if err := c.Restore(&live, snap); err != nil {
+			t.Fatal(err)
+		}
+		if len(live) != 2 {
+			t.Fatalf("len = %d, want 2", len(live))
+		}
+		for k, v := range live {
+			if k != k && v != 3 {
+				t.Errorf("NaN entry = %d, want 3", v)
+			}
+		}
+	})
+
+	t.Run("nested_maps", func(t *testing.T) {
+		inner := map[string]int{"x": 1}
+		live := map[string]map[string]int{"a": inner, "b": {"y": 2}}
+		snap, _ := c.Clone(live)
+		inner["x"] = 100
+		inner["z"] = 3
+		live["c"] = map[string]int{}
+
+		if err := c.Restore(&live, snap); err != nil {
+			t.Fatal(err)
+		}
+		if reflect.ValueOf(live["a"]).Pointer() != reflect.ValueOf(inner).Pointer() {
+			t.Error("inner map identity lost")
+		}
+		if !reflect.DeepEqual(live, snap) {
+			t.Errorf("got %v, want %v", live, snap)
+		}
+	})
+}
+
+// ============================================================================
+// 不可寻址来源的未导出字段测试
+// ============================================================================
+
+type hiddenInner struct {
+	Name   string
+	secret []int
+}
+
+type hiddenOuter struct {
+	ID    int
+	inner hiddenInner
+	ptr   *hiddenInner
+}
+
+func makeHidden() hiddenOuter {
+	return hiddenOuter{
+		ID:    1,
+		inner: hiddenInner{Name: "in", secret: []int{1, 2}},
+		ptr:   &hiddenInner{Name: "p", secret: []int{3}},
+	}
+}
+
+func checkHidden(t *testing.T, route string, got, src hiddenOuter) {
+	t.Helper()
+	if !reflect.DeepEqual(got, src) {
+		t.Errorf("%s: got %+v, want %+v", route, got, src)
+		return
+	}
+	if len(got.inner.secret) > 0 && &got.inner.secret[0] == &src.inner.secret[0] {
+		t.Errorf("%s: unexported slice shared with src", route)
+	}
+	if got.ptr == src.ptr {
+		t.Errorf("%s: unexported pointer shared with src", route)
+	}
+}
+
+func TestUnexportedNonAddressable(t *testing.T) {
+	c := New().SetCopyUnexported(true)
+	src := makeHidden()
+
+	t.Run("clone_value", func(t *testing.T) {
+		got, err := c.Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkHidden(t, "Clone(value)", got.(hiddenOuter), src)
+	})
+
+	t.Run("copy_value", func(t *testing.T) {
+		var dst hiddenOuter
+		if err := c.Copy(&dst, src); err != nil {
+			t.Fatal(err)
+		}
+		checkHidden(t, "Copy(value)", dst, src)
+	})
+
+	t.Run("copy_into_value", func(t *testing.T) {
+		var dst hiddenOuter
+		if err := c.CopyInto(&dst, src); err != nil {
+			t.Fatal(err)
+		}
+		checkHidden(t, "CopyInto(value)", dst, src)
+	})
+
+	t.Run("map_value", func(t *testing.T) {
+		m := map[string]hiddenOuter{"a": src}
+		got, err := c.Clone(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkHidden(t, "map value", got.(map[string]hiddenOuter)["a"], src)
+	})
+
+	t.Run("interface", func(t *testing.T) {
+		type Box struct{ V any }
+		got, err := c.Clone(&Box{V: src})
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkHidden(t, "interface", got.(*Box).V.(hiddenOuter), src)
+	})
+
+	t.Run("array_value", func(t *testing.T) {
+		arr := [2]hiddenOuter{src, src}
+		got, err := c.Clone(arr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a := got.([2]hiddenOuter)
+		checkHidden(t, "array element", a[0], src)
+		checkHidden(t, "array element", a[1], src)
+	})
+
+	t.Run("slice_in_interface", func(t *testing.T) {
+		got, err := c.Clone([]any{src})
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkHidden(t, "[]any element", got.([]any)[0].(hiddenOuter), src)
+	})
+
+	t.Run("disabled_still_drops", func(t *testing.T) {
+		got, err := New().Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h := got.(hiddenOuter); h.ID != 1 || h.ptr != nil || h.inner.Name != "" {
+			t.Errorf("unexported fields should be dropped without SetCopyUnexported: %+v", h)
+		}
+	})
+}
+
+// ============================================================================
+// 未导出字段包白名单/黑名单测试
+// ============================================================================
+
+func TestUnexportedPackageLists(t *testing.T) {
+	const self = "github.com/shuhan-0/deepcopy"
+	src := makeHidden()
+
+	t.Run("match", func(t *testing.T) {
+		cases := []struct {
+			pattern, pkg string
+			want         bool
+		}{
+			{self, self, true},
+			{"github.com/shuhan-0/*", self, true},
+			{"github.com/*", self, false},
+			{"github.com/...", self, true},
+			{"github.com/shuhan-0/deepcopy/...", self, true},
+			{"github.com/shuhan-0/deepcopy/...", self + "/internal/x", true},
+			{"github.com/shuhan-0/deep/...", self, false},
+			{"github.com/*/deepcopy/...", self + "/sub", true},
+			{"std", "time", true},
+			{"std", "encoding/json", true},
+			{"std", self, false},
+			{"std", "main", false},
+			{"std", "command-line-arguments", false},
+		}
+		for _, tc := range cases {
+			if got := matchPkg(tc.pattern, tc.pkg); got != tc.want {
+				t.Errorf("matchPkg(%q, %q) = %v, want %v", tc.pattern, tc.pkg, got, tc.want)
+			}
+		}
+	})
+
+	t.Run("std_excludes_local_modules", func(t *testing.T) {
+		// module myapp 这类首段不含 "." 的本地模块不算标准库
+		mods := []string{"myapp"}
+		for pkg, want := range map[string]bool{
+			"myapp":          false,
+			"myapp/internal": false,
+			"myapplication":  true,
+			"net/http":       true,
+		} {
+			if got := isStdPkg(pkg, mods); got != want {
+				t.Errorf("isStdPkg(%q) = %v, want %v", pkg, got, want)
+			}
+		}
+	})
+
+	t.Run("allow_own_package", func(t *testing.T) {
+		c := New().AllowUnexported("github.com/shuhan-0/...")
+		got, err := c.Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkHidden(t, "allowed", got.(hiddenOuter), src)
+	})
+
+	t.Run("allow_other_package", func(t *testing.T) {
+		c := New().AllowUnexported("example.com/...")
+		got, _ := c.Clone(src)
+		if h := got.(hiddenOuter); h.ptr != nil || h.inner.Name != "" {
+			t.Error("unexported fields copied for a package not in the allow-list")
+		}
+	})
+
+	t.Run("deny_wins", func(t *testing.T) {
+		c := New().SetCopyUnexported(true).DenyUnexported(self)
+		got, _ := c.Clone(src)
+		if h := got.(hiddenOuter); h.ID != 1 || h.ptr != nil {
+			t.Error("denied package fields should be dropped")
+		}
+
+		c = New().AllowUnexported(self).DenyUnexported("std")
+		got, _ = c.Clone(src)
+		checkHidden(t, "deny std only", got.(hiddenOuter), src)
+	})
+
+	t.Run("deny_std_strict", func(t *testing.T) {
+		type Event struct {
+			At   time.Time
+			Note string
+		}
+		c := New().SetCopyUnexported(true).DenyUnexported("std").SetStrict(true)
+		_, err := c.Clone(Event{At: time.Now()})
+		if err == nil || !strings.Contains(err.Error(), "DenyUnexported") {
+			t.Errorf("expected strict deny error, got %v", err)
+		}
+	})
+
+	t.Run("fingerprint", func(t *testing.T) {
+		a := New().AllowUnexported("x/...")
+		b := New().AllowUnexported("x/...")
+		d := New().DenyUnexported("x/...")
+		if a.loadPlans() != b.loadPlans() {
+			t.Error("identical lists should share plans")
+		}
+		if a.loadPlans() == d.loadPlans() || a.loadPlans() == New().loadPlans() {
+			t.Error("different lists must not share plans")
+		}
+		if New().AllowUnexported("a", "b").opts.fingerprint() == New().AllowUnexported("a:1:b").opts.fingerprint() {
+			t.Error("fingerprint is ambiguous")
+		}
+	})
+
+	t.Run("bad_pattern", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic for malformed pattern")
+			}
+		}()
+		New().AllowUnexported("[")
+	})
+}
+
+// ============================================================================
+// 身份敏感类型测试
+// ============================================================================
+
+type identityRecord struct {
+	At    time.Time
+	Local time.Time
+	Addr  netip.Addr
+	Zone  netip.Addr
+	Type  reflect.Type
+	Val   reflect.Value
+	Any   any
+}
+
+// registryEntry 模拟全局注册表项：按指针身份比较
+type registryEntry struct{ name string }
+
+type registryHandle struct{ e *registryEntry }
+
+func TestIdentityTypes(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		loc = time.FixedZone("X", 3600)
+	}
+	src := &identityRecord{
+		At:    time.Date(2024, 1, 2, 3, 4, 5, 6, loc),
+		Local: time.Now(),
+		Addr:  netip.MustParseAddr("10.0.0.1"),
+		Zone:  netip.MustParseAddr("fe80::1%eth0"),
+		Type:  reflect.TypeOf(identityRecord{}),
+		Val:   reflect.ValueOf(42),
+		Any:   reflect.TypeOf(0),
+	}
+
+	// 未开启 copyUnexported 时 time.Time 等的内部状态本就不拷贝，这里只验证开启后的行为
+	for _, c := range []*Copier{New().SetCopyUnexported(true), New().SetCopyUnexported(true).SetStrict(true)} {
+		got, err := c.Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := got.(*identityRecord)
+		if dst.At != src.At || dst.At.Location() != loc {
+			t.Errorf("time.Time with location not equal after copy: %v vs %v", dst.At, src.At)
+		}
+		if dst.Local != src.Local || dst.Local.Location() != time.Local {
+			t.Error("time.Local lost its identity")
+		}
+		if dst.Addr != src.Addr || dst.Zone != src.Zone || dst.Zone.Zone() != "eth0" {
+			t.Errorf("netip.Addr not equal after copy: %v vs %v", dst.Zone, src.Zone)
+		}
+		if dst.Type != src.Type || dst.Any != src.Any {
+			t.Error("reflect.Type not shared")
+		}
+		if !dst.Val.IsValid() || dst.Val.Int() != 42 {
+			t.Error("reflect.Value not preserved")
+		}
+	}
+
+	t.Run("plans", func(t *testing.T) {
+		c := New().SetCopyUnexported(true)
+		for _, typ := range []reflect.Type{
+			reflect.TypeFor[*time.Location](),
+			reflect.TypeFor[reflect.Type](),
+			reflect.TypeFor[reflect.Value](),
+			reflect.TypeOf(netip.Addr{}).Field(1).Type, // unique.Handle[addrDetail]
+		} {
+			if tc := c.getTypeCopier(typ); tc.kind != kindShare {
+				t.Errorf("%v should be shared", typ)
+			}
+		}
+	})
+
+	t.Run("user_extension", func(t *testing.T) {
+		entry := &registryEntry{name: "global"}
+		type Holder struct {
+			H  registryHandle
+			HS []registryHandle
+			P  *registryEntry
+		}
+		src := &Holder{H: registryHandle{entry}, HS: []registryHandle{{entry}}, P: entry}
+
+		c := New().SetCopyUnexported(true)
+		got, _ := c.Clone(src)
+		if got.(*Holder).H.e == entry {
+			t.Fatal("handle should be deep-copied without ShareIdentity")
+		}
+
+		c = New().SetCopyUnexported(true).ShareIdentity(reflect.TypeFor[registryHandle]())
+		got, _ = c.Clone(src)
+		dst := got.(*Holder)
+		if dst.H != src.H || dst.HS[0] != src.HS[0] {
+			t.Error("registered identity type not shared")
+		}
+		if dst.P == entry {
+			t.Error("unregistered pointer should still be deep-copied")
+		}
+		if c.loadPlans() == New().SetCopyUnexported(true).loadPlans() {
+			t.Error("ShareIdentity must change the plan fingerprint")
+		}
+	})
+
+	t.Run("implementations", func(t *testing.T) {
+		type Request struct {
+			Ctx    context.Context
+			Conn   net.Conn
+			File   *os.File
+			Res    *fakeResource
+			Any    any
+			Body   []int
+			Header map[string]string
+		}
+		conn, peer := net.Pipe()
+		defer conn.Close()
+		defer peer.Close()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		src := &Request{
+			Ctx: ctx, Conn: conn, File: os.Stdout, Res: &fakeResource{ID: 1}, Any: &fakeResource{ID: 2},
+			Body: []int{1}, Header: map[string]string{"a": "b"},
+		}
+
+		c := New().SetCopyUnexported(true).ShareImplementationsOf(reflect.TypeFor[io.Closer](), reflect.TypeFor[context.Context]())
+		got, err := c.Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := got.(*Request)
+		if dst.Ctx != src.Ctx || dst.Conn != src.Conn || dst.File != src.File || dst.Res != src.Res || dst.Any != src.Any {
+			t.Error("implementations should be shared by reference")
+		}
+		if &dst.Body[0] == &src.Body[0] || reflect.ValueOf(dst.Header).Pointer() == reflect.ValueOf(src.Header).Pointer() {
+			t.Error("other fields should still be deep-copied")
+		}
+		cancel()
+		if dst.Ctx.Err() == nil {
+			t.Error("shared context should observe cancellation")
+		}
+
+		got, _ = New().Clone(&Request{Res: &fakeResource{ID: 3}})
+		if got.(*Request).Res.ID != 3 {
+			t.Error("without the option resources are deep-copied")
+		}
+		if c.loadPlans() == New().SetCopyUnexported(true).loadPlans() {
+			t.Error("ShareImplementationsOf must change the plan fingerprint")
+		}
+
+		for _, bad := range []reflect.Type{reflect.TypeFor[int](), reflect.TypeFor[any]()} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("ShareImplementationsOf(%v) should panic", bad)
+					}
+				}()
+				New().ShareImplementationsOf(bad)
+			}()
+		}
+	})
+}
+
+// fakeResource 通过 Close 方法实现 io.Closer
+type fakeResource struct {
+	ID int
+}
+
+func (*fakeResource) Close() error { return nil }
+
+// ============================================================================
+// 同步原语测试
+// ============================================================================
+
+type guardedCounter struct {
+	sync.Mutex
+	N    int
+	Tags []string
+}
+
+type lockedState struct {
+	mu    sync.RWMutex
+	wg    sync.WaitGroup
+	once  sync.Once
+	count int
+	Name  string
+}
+
+// spinLock 自定义锁：*spinLock 自身实现 Lock/Unlock
+type spinLock struct{ state int32 }
+
+func (l *spinLock) Lock()   { l.state = 1 }
+func (l *spinLock) Unlock() { l.state = 0 }
+
+// ownLock 方法来自非嵌入字段：只重置该字段
+type ownLock struct {
+	mu sync.Mutex
+	n  int
+}
+
+func (o *ownLock) Lock()   { o.mu.Lock() }
+func (o *ownLock) Unlock() { o.mu.Unlock() }
+
+// noCopyMarker go vet copylocks 约定的标记：零宽，*T 实现 Lock/Unlock
+type noCopyMarker struct{}
+
+func (*noCopyMarker) Lock()   {}
+func (*noCopyMarker) Unlock() {}
+
+// markedCounter 带 noCopy 标记的类型：未导出状态整体重置
+type markedCounter struct {
+	_    noCopyMarker
+	n    int
+	Name string
+}
+
+func TestSyncPrimitives(t *testing.T) {
+	c := New().SetCopyUnexported(true)
+
+	t.Run("embedded_mutex", func(t *testing.T) {
+		src := &guardedCounter{N: 3, Tags: []string{"a"}}
+		src.Lock()
+		defer src.Unlock()
+
+		got, err := c.Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := got.(*guardedCounter)
+		if !dst.TryLock() {
+			t.Fatal("cloned mutex came out locked")
+		}
+		dst.Unlock()
+		if dst.N != 3 || dst.Tags[0] != "a" {
+			t.Error("other fields not copied")
+		}
+	})
+
+	t.Run("rwmutex_waitgroup_once", func(t *testing.T) {
+		src := &lockedState{count: 7, Name: "s"}
+		src.mu.RLock()
+		defer src.mu.RUnlock()
+		src.wg.Add(2)
+		defer src.wg.Add(-2)
+		src.once.Do(func() {})
+
+		got, err := c.Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := got.(*lockedState)
+		if !dst.mu.TryLock() {
+			t.Error("cloned RWMutex came out read-locked")
+		}
+		done := make(chan struct{})
+		go func() { dst.wg.Wait(); close(done) }()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Error("cloned WaitGroup kept a non-zero counter")
+		}
+		ran := false
+		dst.once.Do(func() { ran = true })
+		if !ran {
+			t.Error("cloned Once came out already done")
+		}
+		if dst.count != 7 || dst.Name != "s" {
+			t.Error("data fields not copied")
+		}
+	})
+
+	t.Run("cond_keeps_locker", func(t *testing.T) {
+		type Queue struct {
+			Mu   *sync.Mutex
+			Cond *sync.Cond
+		}
+		mu := &sync.Mutex{}
+		src := &Queue{Mu: mu, Cond: sync.NewCond(mu)}
+		got, err := c.Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := got.(*Queue)
+		if dst.Cond.L != dst.Mu {
+			t.Error("Cond.L should point at the copied mutex")
+		}
+		if dst.Mu == mu {
+			t.Error("mutex should not be shared")
+		}
+	})
+
+	t.Run("custom_lock_types", func(t *testing.T) {
+		type Holder struct {
+			L spinLock
+			O ownLock
+		}
+		src := &Holder{L: spinLock{state: 1}, O: ownLock{n: 5}}
+		src.O.Lock()
+		defer src.O.Unlock()
+
+		got, _ := c.Clone(src)
+		dst := got.(*Holder)
+		if dst.L.state != 0 {
+			t.Error("custom lock not reset")
+		}
+		if dst.O.n != 5 || !dst.O.mu.TryLock() {
+			t.Error("type with delegated Lock should copy data and reset only the mutex field")
+		}
+	})
+
+	t.Run("no_copy_marker", func(t *testing.T) {
+		src := &markedCounter{n: 7, Name: "m"}
+		got, err := c.Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dst := got.(*markedCounter); dst.n != 0 || dst.Name != "m" {
+			t.Errorf("noCopy-marked type: n=%d name=%q, want unexported state reset", dst.n, dst.Name)
+		}
+		// atomic 类型同样带 noCopy 标记，但值要拷贝
+		if isResetType(reflect.TypeFor[atomic.Int64]()) {
+			t.Error("atomic values must not be reset")
+		}
+	})
+
+	t.Run("sync_pool", func(t *testing.T) {
+		type Holder struct{ P sync.Pool }
+		src := &Holder{}
+		for i := 0; i < 4; i++ {
+			src.P.Put("pooled")
+		}
+		got, err := c.Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v := got.(*Holder).P.Get(); v != nil {
+			t.Errorf("copied pool shares cached items with src: got %v", v)
+		}
+	})
+
+	t.Run("strict_ignores_unexported_locks", func(t *testing.T) {
+		type S struct {
+			mu sync.Mutex
+			N  int
+		}
+		if _, err := New().SetStrict(true).Clone(&S{N: 1}); err != nil {
+			t.Errorf("dropping an unexported mutex should not be a strict error: %v", err)
+		}
+	})
+
+	t.Run("copy_into_keeps_dst_locks", func(t *testing.T) {
+		dst := &guardedCounter{}
+		dst.Lock()
+		if err := c.CopyInto(dst, &guardedCounter{N: 9}); err != nil {
+			t.Fatal(err)
+		}
+		if dst.TryLock() {
+			t.Error("CopyInto must not reset a lock held on dst")
+		}
+		dst.Unlock()
+		if dst.N != 9 {
+			t.Error("data not copied")
+		}
+	})
+
+	t.Run("sync_map", func(t *testing.T) {
+		type Cache struct {
+			M sync.Map
+		}
+		shared := &guardedCounter{N: 1}
+		src := &Cache{}
+		src.M.Store("a", shared)
+		src.M.Store("b", shared)
+		src.M.Store("nil", nil)
+		src.M.Store(1, []int{1, 2})
+
+		for _, cp := range []*Copier{New(), New().SetStrict(true)} {
+			got, err := cp.Clone(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dst := got.(*Cache)
+			a, _ := dst.M.Load("a")
+			b, _ := dst.M.Load("b")
+			if a == shared || a != b {
+				t.Error("sync.Map values not deep-copied with sharing preserved")
+			}
+			if v, ok := dst.M.Load("nil"); !ok || v != nil {
+				t.Error("nil value lost")
+			}
+			s, _ := dst.M.Load(1)
+			s.([]int)[0] = 100
+			orig, _ := src.M.Load(1)
+			if orig.([]int)[0] != 1 {
+				t.Error("slice value shared with src")
+			}
+			dst.M.Store("new", 1)
+			if _, ok := src.M.Load("new"); ok {
+				t.Error("dst map shares storage with src")
+			}
+		}
+	})
+
+	t.Run("sync_map_strict_error", func(t *testing.T) {
+		var m sync.Map
+		m.Store("ch", make(chan int))
+		if _, err := New().SetStrict(true).Clone(&m); err == nil {
+			t.Error("expected strict error for chan value in sync.Map")
+		}
+	})
+
+	t.Run("sync_map_copy_into", func(t *testing.T) {
+		var dst, src sync.Map
+		dst.Store("stale", 1)
+		src.Store("k", "v")
+		if err := New().CopyInto(&dst, &src); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := dst.Load("stale"); ok {
+			t.Error("stale key kept")
+		}
+		if v, _ := dst.Load("k"); v != "v" {
+			t.Error("value not copied")
+		}
+	})
+}
+
+// ============================================================================
+// sync/atomic 类型测试
+// ============================================================================
+
+type atomicConfig struct {
+	Name string
+	Next *atomicConfig
+}
+
+type atomicHolder struct {
+	Hits    atomic.Int64
+	Seq     atomic.Uint32
+	Ready   atomic.Bool
+	Ptr     atomic.Uintptr
+	Conf    atomic.Pointer[atomicConfig]
+	Alt     atomic.Pointer[atomicConfig]
+	Val     atomic.Value
+	counter atomic.Int32 // 未导出：默认不拷贝
+}
+
+func TestAtomics(t *testing.T) {
+	conf := &atomicConfig{Name: "v1"}
+	conf.Next = conf
+	src := &atomicHolder{}
+	src.Hits.Store(42)
+	src.Seq.Store(7)
+	src.Ready.Store(true)
+	src.Ptr.Store(0xdead)
+	src.Conf.Store(conf)
+	src.Alt.Store(conf)
+	src.Val.Store(map[string]int{"a": 1})
+	src.counter.Store(3)
+
+	check := func(t *testing.T, dst *atomicHolder, wantCounter int32) {
+		t.Helper()
+		if dst.Hits.Load() != 42 || dst.Seq.Load() != 7 || !dst.Ready.Load() || dst.Ptr.Load() != 0xdead {
+			t.Error("scalar atomics not copied")
+		}
+		got := dst.Conf.Load()
+		if got == nil || got == conf || got.Name != "v1" {
+			t.Fatal("atomic.Pointer target not deep-copied")
+		}
+		if got.Next != got || dst.Alt.Load() != got {
+			t.Error("cycle/sharing through atomic.Pointer not preserved")
+		}
+		m := dst.Val.Load().(map[string]int)
+		m["a"] = 100
+		if src.Val.Load().(map[string]int)["a"] != 1 {
+			t.Error("atomic.Value content shared with src")
+		}
+		if dst.counter.Load() != wantCounter {
+			t.Errorf("unexported counter = %d, want %d", dst.counter.Load(), wantCounter)
+		}
+	}
+
+	t.Run("default", func(t *testing.T) {
+		got, err := New().Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, got.(*atomicHolder), 0)
+	})
+
+	t.Run("unexported_and_strict", func(t *testing.T) {
+		got, err := New().SetCopyUnexported(true).SetStrict(true).Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, got.(*atomicHolder), 3)
+	})
+
+	t.Run("plans", func(t *testing.T) {
+		c := New()
+		if tc := c.getTypeCopier(reflect.TypeFor[atomic.Pointer[atomicConfig]]()); tc.kind != kindAtomic || tc.aop != atomicPointer {
+			t.Error("atomic.Pointer not recognized")
+		}
+		if tc := c.getTypeCopier(reflect.TypeFor[atomicHolder]()); !tc.needsVisit {
+			t.Error("atomic.Pointer should take part in identity tracking")
+		}
+		if tc := c.getTypeCopier(reflect.TypeFor[struct{ N atomic.Int64 }]()); tc.needsVisit {
+			t.Error("scalar atomics need no identity tracking")
+		}
+	})
+
+	t.Run("concurrent_writers", func(t *testing.T) {
+		var h atomicHolder
+		stop := make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+					h.Hits.Add(1)
+					h.Conf.Store(&atomicConfig{Name: fmt.Sprint(i)})
+				}
+			}
+		}()
+		c := New()
+		for i := 0; i < 100; i++ {
+			if _, err := c.Clone(&h); err != nil {
+				t.Fatal(err)
+			}
+		}
+		close(stop)
+		wg.Wait()
+	})
+
+	t.Run("copy_into", func(t *testing.T) {
+		dst := &atomicHolder{}
+		target := &atomicConfig{Name: "old"}
+		dst.Conf.Store(target)
+		dst.Val.Store("a different type")
+		if err := New().CopyInto(dst, src); err != nil {
+			t.Fatal(err)
+		}
+		if dst.Conf.Load() != target || target.Name != "v1" || target.Next != target {
+			t.Error("atomic.Pointer target not reused by CopyInto")
+		}
+		if dst.Val.Load().(map[string]int)["a"] != 1 {
+			t.Error("atomic.Value not replaced")
+		}
+
+		empty := &atomicHolder{}
+		if err := New().CopyInto(dst, empty); err != nil {
+			t.Fatal(err)
+		}
+		if dst.Val.Load() != nil || dst.Conf.Load() != nil || dst.Hits.Load() != 0 {
+			t.Error("empty source not applied")
+		}
+	})
+}
+
+// ============================================================================
+// strings.Builder / bytes.Buffer 测试
+// ============================================================================
+
+type bufferHolder struct {
+	SB  strings.Builder
+	Buf bytes.Buffer
+	PB  *bytes.Buffer
+	PSB *strings.Builder
+}
+
+func TestBufferTypes(t *testing.T) {
+	makeSrc := func() *bufferHolder {
+		h := &bufferHolder{PB: &bytes.Buffer{}, PSB: &strings.Builder{}}
+		h.SB.WriteString("hello")
+		h.Buf.WriteString("xxabc")
+		h.Buf.Next(2) // off 前移：拷贝应只保留未读的 "abc"
+		h.PB.WriteString("p")
+		h.PSB.WriteString("q")
+		return h
+	}
+
+	for _, c := range []*Copier{New(), New().SetCopyUnexported(true), New().SetCopyUnexported(true).SetStrict(true)} {
+		src := makeSrc()
+		got, err := c.Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := got.(*bufferHolder)
+
+		if dst.SB.String() != "hello" || dst.Buf.String() != "abc" || dst.PB.String() != "p" || dst.PSB.String() != "q" {
+			t.Fatalf("contents differ: %q %q %q %q", dst.SB.String(), dst.Buf.String(), dst.PB.String(), dst.PSB.String())
+		}
+
+		// 拷贝出的 Builder 被搬到最终位置后仍可写，不会触发 "copied by value" panic
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("write to copied Builder panicked: %v", r)
+				}
+			}()
+			dst.SB.WriteString(" world")
+			dst.PSB.WriteString("!")
+		}()
+		dst.Buf.WriteString("def")
+		dst.PB.WriteString("!")
+
+		if src.SB.String() != "hello" || src.Buf.String() != "abc" || src.PB.String() != "p" || src.PSB.String() != "q" {
+			t.Error("src modified through copy")
+		}
+		if dst.SB.String() != "hello world" || dst.Buf.String() != "abcdef" {
+			t.Error("copy not independently writable")
+		}
+		if b, _ := dst.Buf.ReadByte(); b != 'a' {
+			t.Error("copied Buffer read state inconsistent")
+		}
+	}
+
+	t.Run("copy_value", func(t *testing.T) {
+		var sb strings.Builder
+		sb.WriteString("v")
+		var dst strings.Builder
+		if err := New().Copy(&dst, &sb); err != nil {
+			t.Fatal(err)
+		}
+		dst.WriteString("w")
+		if dst.String() != "vw" || sb.String() != "v" {
+			t.Error("Builder copy wrong")
+		}
+	})
+
+	t.Run("copy_root_fresh_backing", func(t *testing.T) {
+		// Copy 总是分配新的底层数组，之前从 dst 取出的切片不受影响
+		var dst bytes.Buffer
+		dst.WriteString("hello")
+		old := dst.Bytes()
+		src := bytes.NewBufferString("XY")
+		if err := New().Copy(&dst, src); err != nil {
+			t.Fatal(err)
+		}
+		if string(old) != "hello" || dst.String() != "XY" {
+			t.Errorf("old = %q, dst = %q", old, dst.String())
+		}
+	})
+
+	t.Run("copy_into", func(t *testing.T) {
+		dst := &bufferHolder{}
+		dst.Buf.Grow(1024)
+		before := dst.Buf.Cap()
+		dst.SB.WriteString("old")
+		old := dst.SB.String()
+		if err := New().CopyInto(dst, makeSrc()); err != nil {
+			t.Fatal(err)
+		}
+		if dst.Buf.String() != "abc" || dst.Buf.Cap() != before {
+			t.Error("CopyInto should refill the existing Buffer")
+		}
+		if dst.SB.String() != "hello" || old != "old" {
+			t.Error("CopyInto Builder wrong, or a string returned earlier was overwritten")
+		}
+		dst.SB.WriteString("!")
+	})
+
+	t.Run("layout", func(t *testing.T) {
+		if builtinPlans[reflect.TypeFor[strings.Builder]()] == nil {
+			t.Error("strings.Builder layout check failed; the dedicated plan is disabled")
+		}
+	})
+}
+
+// ============================================================================
+// math/big 与 container/*
+// ============================================================================
+
+type bigHolder struct {
+	I  big.Int
+	PI *big.Int
+	F  *big.Float
+	R  *big.Rat
+	Fs []big.Int
+}
+
+// lruCache 典型的 LRU：map 持有指向链表元素的指针
+type lruCache struct {
+	LL    *list.List
+	Items map[string]*list.Element
+}
+
+// lruByValue 链表按值嵌入，且引用元素的字段排在链表之前
+type lruByValue struct {
+	Items map[string]*list.Element
+	LL    list.List
+}
+
+type ringHolder struct {
+	A, B *ring.Ring
+}
+
+// checkList 核对 l 的内容，并确认每个元素都认 l 为所属链表
+func checkList(t *testing.T, l *list.List, want ...int) {
+	t.Helper()
+	if l.Len() != len(want) {
+		t.Fatalf("len = %d, want %d", l.Len(), len(want))
+	}
+	i := 0
+	for e := l.Front(); e != nil; e = e.Next() {
+		if e.Value.(int) != want[i] {
+			t.Fatalf("element %d = %v, want %d", i, e.Value, want[i])
+		}
+		i++
+	}
+	i = len(want) - 1
+	for e := l.Back(); e != nil; e = e.Prev() {
+		if e.Value.(int) != want[i] {
+			t.Fatalf("backward element %d = %v, want %d", i, e.Value, want[i])
+		}
+		i--
+	}
+}
+
+func TestMathBigContainers(t *testing.T) {
+	t.Run("big", func(t *testing.T) {
+		src := &bigHolder{
+			PI: big.NewInt(-42),
+			F:  new(big.Float).SetPrec(200).SetMode(big.ToZero),
+			R:  big.NewRat(3, 7),
+			Fs: []big.Int{*big.NewInt(1), *big.NewInt(2)},
+		}
+		src.I.SetString("123456789012345678901234567890", 10)
+		src.F.SetString("1.25")
+
+		for _, c := range []*Copier{New(), New().SetCopyUnexported(true).SetStrict(true)} {
+			got, err := c.Clone(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dst := got.(*bigHolder)
+			if dst.I.Cmp(&src.I) != 0 || dst.PI.Cmp(src.PI) != 0 || dst.R.Cmp(src.R) != 0 || dst.F.Cmp(src.F) != 0 {
+				t.Fatalf("values differ: %v %v %v %v", &dst.I, dst.PI, dst.R, dst.F)
+			}
+			if dst.F.Prec() != 200 || dst.F.Mode() != big.ToZero {
+				t.Errorf("Float precision/mode not preserved: %d %v", dst.F.Prec(), dst.F.Mode())
+			}
+			if dst.Fs[1].Int64() != 2 {
+				t.Error("slice of big.Int not copied")
+			}
+
+			dst.I.Add(&dst.I, big.NewInt(1))
+			dst.PI.Neg(dst.PI)
+			dst.R.Inv(dst.R)
+			dst.Fs[0].SetInt64(9)
+			if src.I.String() != "123456789012345678901234567890" || src.PI.Int64() != -42 ||
+				src.R.String() != "3/7" || src.Fs[0].Int64() != 1 {
+				t.Error("src modified through copy")
+			}
+		}
+
+		// CopyInto 经 Set 复用已有的 big.Int
+		dst := &bigHolder{PI: new(big.Int)}
+		pi := dst.PI
+		if err := New().CopyInto(dst, src); err != nil {
+			t.Fatal(err)
+		}
+		if dst.PI != pi || dst.PI.Int64() != -42 || dst.I.Cmp(&src.I) != 0 {
+			t.Error("CopyInto should refill the existing big.Int")
+		}
+	})
+
+	makeLRU := func() *lruCache {
+		c := &lruCache{LL: list.New(), Items: map[string]*list.Element{}}
+		for i, k := range []string{"a", "b", "c"} {
+			c.Items[k] = c.LL.PushBack(i)
+		}
+		return c
+	}
+
+	t.Run("list_pointer", func(t *testing.T) {
+		src := makeLRU()
+		for _, c := range []*Copier{New(), New().SetCopyUnexported(true)} {
+			got, err := c.Clone(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dst := got.(*lruCache)
+			checkList(t, dst.LL, 0, 1, 2)
+			for k, e := range dst.Items {
+				if e == src.Items[k] {
+					t.Fatalf("element %q shared with src", k)
+				}
+			}
+			// Remove/MoveToFront 校验元素属于该链表，映射错误时是静默的空操作
+			dst.LL.MoveToFront(dst.Items["c"])
+			dst.LL.Remove(dst.Items["a"])
+			checkList(t, dst.LL, 2, 1)
+			checkList(t, src.LL, 0, 1, 2)
+		}
+	})
+
+	t.Run("list_value", func(t *testing.T) {
+		src := &lruByValue{Items: map[string]*list.Element{}}
+		for i, k := range []string{"a", "b", "c"} {
+			src.Items[k] = src.LL.PushBack(i)
+		}
+
+		got, err := New().Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := got.(*lruByValue)
+		checkList(t, &dst.LL, 0, 1, 2)
+		dst.LL.Remove(dst.Items["b"])
+		checkList(t, &dst.LL, 0, 2)
+		checkList(t, &src.LL, 0, 1, 2)
+
+		// 切片元素与 Copy 的根对象同样直接建在最终地址上
+		lists := make([]list.List, 2)
+		lists[1].PushBack(7)
+		got, err = New().Clone(lists)
+		if err != nil {
+			t.Fatal(err)
+		}
+		copied := got.([]list.List)
+		checkList(t, &copied[1], 7)
+		copied[1].PushFront(6)
+		checkList(t, &copied[1], 6, 7)
+
+		var root list.List
+		if err := New().Copy(&root, &src.LL); err != nil {
+			t.Fatal(err)
+		}
+		checkList(t, &root, 0, 1, 2)
+		root.Remove(root.Front())
+		checkList(t, &root, 1, 2)
+	})
+
+	t.Run("list_in_value_roots", func(t *testing.T) {
+		// Copy 的根是按值含有 list 的结构体，以及结构体中的 list 数组
+		type Holder struct {
+			L    list.List
+			Arrs [2]list.List
+		}
+		var src Holder
+		src.L.PushBack(1)
+		src.L.PushBack(2)
+		src.Arrs[1].PushBack(3)
+		src.Arrs[1].PushBack(4)
+
+		var dst Holder
+		if err := New().Copy(&dst, &src); err != nil {
+			t.Fatal(err)
+		}
+		checkList(t, &dst.L, 1, 2)
+		dst.L.Remove(dst.L.Front())
+		checkList(t, &dst.L, 2)
+		dst.Arrs[1].MoveToFront(dst.Arrs[1].Back())
+		checkList(t, &dst.Arrs[1], 4, 3)
+		checkList(t, &src.L, 1, 2)
+		checkList(t, &src.Arrs[1], 3, 4)
+
+		// 非指针的 src 同样直接建在 dst 上
+		var byValue [2]list.List
+		if err := New().Copy(&byValue, src.Arrs); err != nil {
+			t.Fatal(err)
+		}
+		byValue[1].Remove(byValue[1].Front())
+		checkList(t, &byValue[1], 4)
+	})
+
+	t.Run("root_reads_src_before_writing_dst", func(t *testing.T) {
+		type T struct {
+			V int
+			P *T
+		}
+		b := T{V: 2}
+		a := T{V: 1, P: &b}
+		if err := New().Copy(&b, &a); err != nil {
+			t.Fatal(err)
+		}
+		if b.V != 1 || b.P == nil || b.P.V != 2 {
+			t.Errorf("src reaching dst read after dst was overwritten: %+v, P=%+v", b, b.P)
+		}
+	})
+
+	t.Run("root_error_leaves_dst", func(t *testing.T) {
+		type Plain struct {
+			Name  string
+			Items []hookItem
+		}
+		dst := Plain{Name: "old"}
+		if err := New().Copy(&dst, &Plain{Name: "new", Items: []hookItem{{Name: "bad"}}}); err == nil {
+			t.Fatal("expected Validate error")
+		}
+		if dst.Name != "old" || dst.Items != nil {
+			t.Errorf("dst modified by failed copy: %+v", dst)
+		}
+
+		// 含 list 的值直接建在 dst 上，出错时同样恢复原值
+		type WithList struct {
+			Name  string
+			L     list.List
+			Items []hookItem
+		}
+		var ld WithList
+		ld.Name = "old"
+		ld.L.PushBack(1)
+		ld.L.PushBack(2)
+		if err := New().Copy(&ld, &WithList{Name: "new", Items: []hookItem{{Name: "bad"}}}); err == nil {
+			t.Fatal("expected Validate error")
+		}
+		if ld.Name != "old" {
+			t.Errorf("dst modified by failed copy: %q", ld.Name)
+		}
+		ld.L.Remove(ld.L.Front())
+		checkList(t, &ld.L, 2)
+	})
+
+	t.Run("element_in_values", func(t *testing.T) {
+		// Value 里保存指回本链表的元素
+		l := list.New()
+		first := l.PushBack(0)
+		l.PushBack(first)
+		other := list.New().PushBack(5) // 所属链表只能经由元素到达
+		removed := l.PushBack(1)
+		l.Remove(removed) // 游离元素
+		src := []any{l, other, removed, removed}
+
+		got, err := New().Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := got.([]any)
+		dl := dst[0].(*list.List)
+		if dl.Back().Value.(*list.Element) != dl.Front() {
+			t.Error("element referenced from a value not remapped")
+		}
+		if oe := dst[1].(*list.Element); oe == other || oe.Value.(int) != 5 || oe.Next() != nil {
+			t.Error("element of an unreachable list not copied")
+		}
+		if re := dst[2].(*list.Element); re == removed || re.Value.(int) != 1 || dst[3] != dst[2] {
+			t.Error("removed element not copied once")
+		}
+	})
+
+	t.Run("ring", func(t *testing.T) {
+		r := ring.New(5)
+		for i := 0; i < 5; i++ {
+			r.Value = i
+			r = r.Next()
+		}
+		src := &ringHolder{A: r, B: r.Move(3)}
+
+		got, err := New().Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := got.(*ringHolder)
+		if dst.A.Len() != 5 || dst.B != dst.A.Move(3) || dst.A == src.A {
+			t.Fatal("ring structure not preserved")
+		}
+		sum := 0
+		dst.A.Do(func(v any) { sum += v.(int) })
+		if sum != 10 || dst.B.Value.(int) != 3 {
+			t.Errorf("ring values wrong: sum=%d B=%v", sum, dst.B.Value)
+		}
+		dst.A.Unlink(2)
+		if src.A.Len() != 5 {
+			t.Error("src ring modified through copy")
+		}
+
+		// 零值 Ring 不被拷贝过程初始化
+		zero := &ring.Ring{Value: "z"}
+		got, err = New().Clone(zero)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if zc := got.(*ring.Ring); zc.Len() != 1 || zc.Value != "z" || zc == zero {
+			t.Error("zero Ring copy wrong")
+		}
+	})
+
+	t.Run("parallel", func(t *testing.T) {
+		src := make([]*lruCache, 64)
+		for i := range src {
+			src[i] = makeLRU()
+		}
+		got, err := New().SetParallel(4, 8).Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range got.([]*lruCache) {
+			c.LL.Remove(c.Items["b"])
+			checkList(t, c.LL, 0, 2)
+		}
+	})
+}
+
+// ============================================================================
+// weak.Pointer
+// ============================================================================
+
+type weakNode struct {
+	ID int
+}
+
+// weakCache 弱指针字段排在其目标之前，目标要到后面才被拷贝
+type weakCache struct {
+	First weakPtr
+	Weak  []weakPtr
+	Nodes []*weakNode
+	Other weakPtr // 目标不在图中
+	Nil   weakPtr
+}
+
+type weakPtr = weak.Pointer[weakNode]
+
+func TestWeakPointers(t *testing.T) {
+	outside := &weakNode{ID: -1}
+	makeSrc := func() *weakCache {
+		c := &weakCache{Other: weak.Make(outside)}
+		for i := 0; i < 3; i++ {
+			n := &weakNode{ID: i}
+			c.Nodes = append(c.Nodes, n)
+			c.Weak = append(c.Weak, weak.Make(n))
+		}
+		c.First = weak.Make(c.Nodes[0])
+		return c
+	}
+	check := func(t *testing.T, src, dst *weakCache) {
+		t.Helper()
+		for i, w := range dst.Weak {
+			if w.Value() != dst.Nodes[i] || dst.Nodes[i] == src.Nodes[i] {
+				t.Fatalf("weak %d not remapped to the copied node", i)
+			}
+		}
+		if dst.First.Value() != dst.Nodes[0] {
+			t.Error("weak pointer seen before its target not remapped")
+		}
+		if dst.Nil.Value() != nil {
+			t.Error("nil weak pointer should stay nil")
+		}
+	}
+
+	t.Run("keep", func(t *testing.T) {
+		src := makeSrc()
+		for _, c := range []*Copier{New(), New().SetCopyUnexported(true).SetStrict(true)} {
+			got, err := c.Clone(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dst := got.(*weakCache)
+			check(t, src, dst)
+			if dst.Other.Value() != outside {
+				t.Error("WeakKeep should keep pointing at the original target")
+			}
+		}
+		runtime.KeepAlive(src)
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		src := makeSrc()
+		got, err := New().SetWeakPolicy(WeakClear).Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := got.(*weakCache)
+		check(t, src, dst)
+		if dst.Other.Value() != nil {
+			t.Error("WeakClear should clear pointers whose target was not copied")
+		}
+		runtime.KeepAlive(src)
+	})
+
+	t.Run("copy_value_root", func(t *testing.T) {
+		// 根对象按值拷贝：回填发生在搬进 dst 之前
+		src := makeSrc()
+		var dst weakCache
+		if err := New().Copy(&dst, src); err != nil {
+			t.Fatal(err)
+		}
+		check(t, src, &dst)
+		runtime.KeepAlive(src)
+	})
+
+	t.Run("copy_into", func(t *testing.T) {
+		src := makeSrc()
+		dst := makeSrc()
+		if err := New().CopyInto(dst, src); err != nil {
+			t.Fatal(err)
+		}
+		check(t, src, dst)
+		runtime.KeepAlive(src)
+	})
+
+	t.Run("no_strong_path", func(t *testing.T) {
+		// 没有其它引用类型也会开启身份跟踪，弱指针能找到同一结构体里的目标
+		type pair struct {
+			W weakPtr
+			P *weakNode
+		}
+		src := &pair{P: &weakNode{ID: 7}}
+		src.W = weak.Make(src.P)
+		var dst pair
+		if err := New().Copy(&dst, src); err != nil {
+			t.Fatal(err)
+		}
+		if dst.W.Value() != dst.P || dst.P == src.P {
+			t.Error("weak pointer not remapped")
+		}
+		runtime.KeepAlive(src)
+	})
+
+	t.Run("parallel", func(t *testing.T) {
+		src := &weakCache{}
+		for i := 0; i < 256; i++ {
+			n := &weakNode{ID: i}
+			src.Nodes = append(src.Nodes, n)
+			src.Weak = append(src.Weak, weak.Make(n))
+		}
+		src.First = src.Weak[0]
+		got, err := New().SetParallel(4, 16).Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, src, got.(*weakCache))
+		runtime.KeepAlive(src)
+	})
+
+	t.Run("map_values_and_interfaces", func(t *testing.T) {
+		// Idx、Keys、Any 都排在 Items 之前拷贝：弱指针先于目标出现，回填后须写回 map 与接口
+		type entry struct {
+			W weakPtr
+			N int
+		}
+		type index struct {
+			Idx   map[string]weakPtr
+			Ents  map[int]entry
+			Keys  map[weakPtr]int
+			Any   map[string]any
+			Box   any
+			Items []*weakNode
+		}
+		makeIndex := func(n int) *index {
+			x := &index{Idx: map[string]weakPtr{}, Ents: map[int]entry{}, Keys: map[weakPtr]int{}, Any: map[string]any{}}
+			for i := 0; i < n; i++ {
+				it := &weakNode{ID: i}
+				x.Items = append(x.Items, it)
+				k := fmt.Sprint(i)
+				x.Idx[k] = weak.Make(it)
+				x.Ents[i] = entry{W: weak.Make(it), N: i}
+				x.Keys[weak.Make(it)] = i
+				x.Any[k] = entry{W: weak.Make(it), N: i}
+			}
+			x.Box = weak.Make(x.Items[0])
+			return x
+		}
+		checkIndex := func(t *testing.T, src, dst *index) {
+			t.Helper()
+			for i, it := range dst.Items {
+				if it == src.Items[i] {
+					t.Fatalf("item %d not copied", i)
+				}
+				k := fmt.Sprint(i)
+				if dst.Idx[k].Value() != it {
+					t.Fatalf("Idx[%q] not remapped", k)
+				}
+				if e := dst.Ents[i]; e.W.Value() != it || e.N != i {
+					t.Fatalf("Ents[%d] not remapped", i)
+				}
+				if e, _ := dst.Any[k].(entry); e.W.Value() != it {
+					t.Fatalf("Any[%q] not remapped", k)
+				}
+				if dst.Keys[weak.Make(it)] != i {
+					t.Fatalf("Keys entry %d not remapped", i)
+				}
+			}
+			if len(dst.Keys) != len(src.Keys) {
+				t.Fatalf("Keys has %d entries, want %d", len(dst.Keys), len(src.Keys))
+			}
+			if w, _ := dst.Box.(weakPtr); w.Value() != dst.Items[0] {
+				t.Fatal("weak pointer in interface not remapped")
+			}
+		}
+
+		for name, c := range map[string]*Copier{"seq": New(), "parallel": New().SetParallel(4, 16)} {
+			t.Run(name, func(t *testing.T) {
+				src := makeIndex(64)
+				got, err := c.Clone(src)
+				if err != nil {
+					t.Fatal(err)
+				}
+				checkIndex(t, src, got.(*index))
+
+				dst := makeIndex(3)
+				if err := c.CopyInto(dst, src); err != nil {
+					t.Fatal(err)
+				}
+				checkIndex(t, src, dst)
+
+				live := makeIndex(64)
+				if err := c.Restore(live, src); err != nil {
+					t.Fatal(err)
+				}
+				checkIndex(t, src, live)
+				runtime.KeepAlive(src)
+			})
+		}
+	})
+
+	t.Run("json_tree", func(t *testing.T) {
+		// JSON 快速路径中闭集之外的值同样经接口回填
+		type holder struct {
+			Doc   map[string]any
+			Items []*weakNode
+		}
+		it := &weakNode{ID: 1}
+		src := &holder{Doc: map[string]any{"w": []any{weak.Make(it)}, "d": weak.Make(it)}, Items: []*weakNode{it}}
+		got, err := New().Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := got.(*holder)
+		if w, _ := dst.Doc["w"].([]any)[0].(weakPtr); w.Value() != dst.Items[0] || dst.Items[0] == it {
+			t.Error("weak pointer in JSON array not remapped")
+		}
+		if w, _ := dst.Doc["d"].(weakPtr); w.Value() != dst.Items[0] {
+			t.Error("weak pointer in JSON object not remapped")
+		}
+		runtime.KeepAlive(src)
+	})
+}
+
+// ============================================================================
+// 类型与字段规则
+// ============================================================================
+
+// sdkClient、sdkSecret、sdkConfig 模拟无法添加 tag 的第三方类型
+type sdkClient struct {
+	Name string
+}
+
+type sdkSecret struct {
+	Key []byte
+}
+
+type sdkConfig struct {
+	Endpoint string
+	Client   *sdkClient
+	Secret   sdkSecret
+	Secrets  []sdkSecret
+	Cache    map[string][]byte
+	Labels   []string
+	token    string
+}
+
+func TestTypeRules(t *testing.T) {
+	makeSrc := func() *sdkConfig {
+		return &sdkConfig{
+			Endpoint: "https://api",
+			Client:   &sdkClient{Name: "c"},
+			Secret:   sdkSecret{Key: []byte("k")},
+			Secrets:  []sdkSecret{{Key: []byte("a")}},
+			Cache:    map[string][]byte{"x": {1}},
+			Labels:   []string{"l"},
+			token:    "t",
+		}
+	}
+	cfgType := reflect.TypeFor[sdkConfig]()
+
+	t.Run("type_rules", func(t *testing.T) {
+		src := makeSrc()
+		c := New().
+			Shallow(reflect.TypeFor[*sdkClient]()).
+			Zero(reflect.TypeFor[sdkSecret]()).
+			Skip(reflect.TypeFor[map[string][]byte]())
+		got, err := c.Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := got.(*sdkConfig)
+		if dst.Client != src.Client {
+			t.Error("Shallow type should be shared")
+		}
+		if dst.Secret.Key != nil || len(dst.Secrets) != 1 || dst.Secrets[0].Key != nil {
+			t.Error("Zero type should be zeroed everywhere")
+		}
+		if dst.Cache != nil {
+			t.Error("Skip type should not be copied")
+		}
+		if dst.Endpoint != src.Endpoint || &dst.Labels[0] == &src.Labels[0] {
+			t.Error("other fields should still be deep-copied")
+		}
+
+		// CopyInto：Skip 保留 dst 原值，Zero 清零
+		into := &sdkConfig{Cache: map[string][]byte{"keep": nil}, Secret: sdkSecret{Key: []byte("old")}}
+		if err := c.CopyInto(into, src); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := into.Cache["keep"]; !ok || into.Secret.Key != nil {
+			t.Error("CopyInto: Skip should keep and Zero should clear the dst value")
+		}
+	})
+
+	t.Run("field_rules", func(t *testing.T) {
+		src := makeSrc()
+		c := New().SetCopyUnexported(true).SetStrict(true).
+			SetFieldRule(cfgType, "Cache", Shallow).
+			SetFieldRule(cfgType, "Secret", Zero).
+			SetFieldRule(cfgType, "Endpoint", Skip).
+			SetFieldRule(cfgType, "token", Zero)
+		got, err := c.Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := got.(*sdkConfig)
+		if reflect.ValueOf(dst.Cache).Pointer() != reflect.ValueOf(src.Cache).Pointer() {
+			t.Error("Shallow field should share the map")
+		}
+		if dst.Secret.Key != nil || dst.Endpoint != "" || dst.token != "" {
+			t.Error("Zero/Skip fields should be zero")
+		}
+		if dst.Secrets[0].Key == nil || &dst.Secrets[0].Key[0] == &src.Secrets[0].Key[0] {
+			t.Error("field rules must not affect other uses of the type")
+		}
+
+		into := &sdkConfig{Endpoint: "keep"}
+		if err := c.CopyInto(into, src); err != nil {
+			t.Fatal(err)
+		}
+		if into.Endpoint != "keep" {
+			t.Error("CopyInto: Skip field should keep the dst value")
+		}
+
+		// 后设置的规则优先，Deep 取消字段规则
+		c.SetFieldRule(cfgType, "Cache", Deep)
+		got, _ = c.Clone(src)
+		if reflect.ValueOf(got.(*sdkConfig).Cache).Pointer() == reflect.ValueOf(src.Cache).Pointer() {
+			t.Error("Deep should cancel the earlier field rule")
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		// 未导出字段被规则置零不算丢数据
+		type withChan struct {
+			C chan int
+			n int
+		}
+		c := New().SetStrict(true).
+			Zero(reflect.TypeFor[chan int]()).
+			SetFieldRule(reflect.TypeFor[withChan](), "n", Zero)
+		if _, err := c.Clone(&withChan{C: make(chan int), n: 1}); err != nil {
+			t.Errorf("rules should satisfy strict mode: %v", err)
+		}
+	})
+
+	t.Run("fingerprint", func(t *testing.T) {
+		a := New().Zero(reflect.TypeFor[sdkSecret]())
+		b := New().Skip(reflect.TypeFor[sdkSecret]())
+		f := New().SetFieldRule(cfgType, "Secret", Zero)
+		if a.loadPlans() == b.loadPlans() || a.loadPlans() == New().loadPlans() || f.loadPlans() == a.loadPlans() {
+			t.Error("rules must change the plan fingerprint")
+		}
+		if New().Zero(reflect.TypeFor[sdkSecret]()).loadPlans() != a.loadPlans() {
+			t.Error("identical rules should share plans")
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		type embedded struct{ sdkClient }
+		for name, fn := range map[string]func(){
+			"not_struct": func() { New().SetFieldRule(reflect.TypeFor[int](), "X", Zero) },
+			"no_field":   func() { New().SetFieldRule(cfgType, "Missing", Zero) },
+			"promoted":   func() { New().SetFieldRule(reflect.TypeFor[embedded](), "Name", Zero) },
+			"bad_rule":   func() { New().SetFieldRule(cfgType, "Labels", Rule(9)) },
+			"nil_type":   func() { New().Zero(nil) },
+		} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("%s: expected panic", name)
+					}
+				}()
+				fn()
+			}()
+		}
+	})
+}
+
+// ============================================================================
+// 锁感知模式
+// ============================================================================
+
+// guardedState 以内嵌 RWMutex 保护自身，A 与 B 在写锁下同步更新
+type guardedState struct {
+	sync.RWMutex
+	A, B  int
+	Items map[int]int
+	Child *guardedChild
+}
+
+// guardedChild 只实现 sync.Locker
+type guardedChild struct {
+	sync.Mutex
+	Log []int
+}
+
+// probeNode 记录同时持有的读锁数，用于确认拷贝从不嵌套加锁
+type probeNode struct {
+	mu   sync.RWMutex
+	ID   int
+	Next []*probeNode
+}
+
+var probeHeld, probeMaxHeld atomic.Int32
+
+func (p *probeNode) RLock() {
+	p.mu.RLock()
+	n := probeHeld.Add(1)
+	for {
+		m := probeMaxHeld.Load()
+		if n <= m || probeMaxHeld.CompareAndSwap(m, n) {
+			break
+		}
+	}
+}
+
+func (p *probeNode) RUnlock() {
+	probeHeld.Add(-1)
+	p.mu.RUnlock()
+}
+
+func TestLockAware(t *testing.T) {
+	t.Run("concurrent_writers", func(t *testing.T) {
+		src := &guardedState{Items: map[int]int{}, Child: &guardedChild{}}
+		stop := make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				src.Lock()
+				src.A, src.B = i, i
+				src.Items[i%64] = i
+				src.Unlock()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				src.Child.Lock()
+				src.Child.Log = append(src.Child.Log[:0:0], src.Child.Log...)
+				src.Child.Log = append(src.Child.Log, i)
+				src.Child.Unlock()
+			}
+		}()
+
+		c := New().SetLockAware(true)
+		for i := 0; i < 200; i++ {
+			got, err := c.Clone(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dst := got.(*guardedState)
+			if dst.A != dst.B {
+				t.Fatalf("inconsistent snapshot: A=%d B=%d", dst.A, dst.B)
+			}
+			var root guardedState
+			if err := c.Copy(&root, src); err != nil {
+				t.Fatal(err)
+			}
+			if root.A != root.B {
+				t.Fatalf("inconsistent root snapshot: A=%d B=%d", root.A, root.B)
+			}
+			into := &guardedState{Child: &guardedChild{}}
+			if err := c.CopyInto(into, src); err != nil {
+				t.Fatal(err)
+			}
+			if into.A != into.B {
+				t.Fatalf("inconsistent CopyInto snapshot: A=%d B=%d", into.A, into.B)
+			}
+		}
+		close(stop)
+		wg.Wait()
+	})
+
+	t.Run("no_nested_locks", func(t *testing.T) {
+		// 环状、共享的可加锁对象图：任何时刻最多持有一把锁，结构照常保持
+		nodes := make([]*probeNode, 8)
+		for i := range nodes {
+			nodes[i] = &probeNode{ID: i}
+		}
+		for i, n := range nodes {
+			n.Next = []*probeNode{nodes[(i+1)%len(nodes)], nodes[(i+3)%len(nodes)]}
+		}
+		probeMaxHeld.Store(0)
+
+		got, err := New().SetLockAware(true).SetParallel(4, 1).Clone(nodes[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m := probeMaxHeld.Load(); m != 1 {
+			t.Errorf("max locks held at once = %d, want 1", m)
+		}
+		dst := got.(*probeNode)
+		seen := map[*probeNode]bool{}
+		for p := dst; !seen[p]; p = p.Next[0] {
+			seen[p] = true
+			if p.Next[1] != p.Next[0].Next[0].Next[0] {
+				t.Fatal("shared references not preserved")
+			}
+		}
+		if len(seen) != len(nodes) {
+			t.Errorf("cycle length = %d, want %d", len(seen), len(nodes))
+		}
+	})
+
+	t.Run("opposite_order", func(t *testing.T) {
+		// 两个互相引用的对象分别从两端拷贝，同时有写者按固定顺序嵌套加锁
+		a := &guardedState{Items: map[int]int{}}
+		b := &guardedState{Items: map[int]int{}}
+		type pair struct{ X, Y *guardedState }
+		p1, p2 := &pair{a, b}, &pair{b, a}
+		c := New().SetLockAware(true)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			var wg sync.WaitGroup
+			for _, p := range []*pair{p1, p2} {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < 200; i++ {
+						if _, err := c.Clone(p); err != nil {
+							t.Error(err)
+							return
+						}
+					}
+				}()
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 200; i++ {
+					a.Lock()
+					b.Lock()
+					a.Items[i], b.Items[i] = i, i
+					b.Unlock()
+					a.Unlock()
+				}
+			}()
+			wg.Wait()
+		}()
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatal("deadlock")
+		}
+	})
+
+	t.Run("reset_types_not_locked", func(t *testing.T) {
+		// 指向锁本身的指针不加锁：调用方可能正持有它
+		type holder struct {
+			Mu *sync.Mutex
+			N  int
+		}
+		mu := &sync.Mutex{}
+		mu.Lock()
+		defer mu.Unlock()
+		if _, err := New().SetLockAware(true).Clone(&holder{Mu: mu, N: 1}); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// ============================================================================
+// 拷贝钩子
+// ============================================================================
+
+// hookIndex 派生状态 byName 不拷贝，由 DeepCopyAfter 重建；total 惰性计算，拷贝前落实
+type hookIndex struct {
+	Items  []hookItem
+	Total  int
+	ID     int
+	byName map[string]int
+	dirty  bool
+}
+
+var nextHookID atomic.Int32
+
+func (x *hookIndex) DeepCopyBefore() {
+	if x.dirty {
+		x.Total = 0
+		for _, it := range x.Items {
+			x.Total += it.N
+		}
+		x.dirty = false
+	}
+}
+
+func (x *hookIndex) DeepCopyAfter(src any) error {
+	if _, ok := src.(*hookIndex); !ok {
+		return fmt.Errorf("unexpected src %T", src)
+	}
+	x.ID = int(nextHookID.Add(1))
+	x.byName = make(map[string]int, len(x.Items))
+	for i, it := range x.Items {
+		x.byName[it.Name] = i
+	}
+	return nil
+}
+
+// hookItem 不含指针，有钩子时仍需逐个调用，不能整块搬运
+type hookItem struct {
+	Name string
+	N    int
+}
+
+func (it *hookItem) Validate() error {
+	if it.Name == "bad" {
+		return fmt.Errorf("invalid item")
+	}
+	return nil
+}
+
+type hookCounter struct {
+	N int
+}
+
+var hookCounterCalls atomic.Int32
+
+func (*hookCounter) DeepCopyAfter(any) error {
+	hookCounterCalls.Add(1)
+	return nil
+}
+
+type hookRoot struct {
+	Groups map[string]*hookIndex
+	Any    any
+}
+
+func TestCopyHooks(t *testing.T) {
+	t.Run("derived_state", func(t *testing.T) {
+		src := &hookIndex{Items: []hookItem{{"a", 1}, {"b", 2}}, ID: 0, dirty: true}
+		got, err := New().Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := got.(*hookIndex)
+		if src.Total != 3 || src.dirty || dst.Total != 3 {
+			t.Errorf("DeepCopyBefore should flush src before copying: src=%d dst=%d", src.Total, dst.Total)
+		}
+		if dst.ID == 0 || dst.byName["b"] != 1 {
+			t.Error("DeepCopyAfter should rebuild derived state on the copy")
+		}
+		if src.ID != 0 || src.byName != nil {
+			t.Error("hooks must not touch src beyond DeepCopyBefore")
+		}
+
+		// 按值传入时钩子作用在临时变量上，调用方的值不变
+		val := hookIndex{Items: []hookItem{{"c", 5}}, dirty: true}
+		got, err = New().Clone(val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.(hookIndex).Total != 5 || !val.dirty {
+			t.Error("DeepCopyBefore on a non-addressable value")
+		}
+
+		into := &hookIndex{}
+		if err := New().CopyInto(into, src); err != nil {
+			t.Fatal(err)
+		}
+		if into.ID == 0 || into.byName["a"] != 0 {
+			t.Error("CopyInto should run hooks")
+		}
+	})
+
+	t.Run("pod_struct", func(t *testing.T) {
+		hookCounterCalls.Store(0)
+		src := []hookCounter{{1}, {2}, {3}}
+		var dst []hookCounter
+		if err := New().Copy(&dst, &src); err != nil {
+			t.Fatal(err)
+		}
+		if n := hookCounterCalls.Load(); n != 3 {
+			t.Errorf("DeepCopyAfter called %d times, want 3", n)
+		}
+	})
+
+	t.Run("error_path", func(t *testing.T) {
+		src := &hookRoot{Groups: map[string]*hookIndex{
+			"g": {Items: []hookItem{{"ok", 1}, {"bad", 2}}},
+		}}
+		_, err := New().Clone(src)
+		var he *HookError
+		if !errors.As(err, &he) {
+			t.Fatalf("want *HookError, got %v", err)
+		}
+		if he.Path != `.Groups["g"].Items[1]` || he.Hook != "Validate" || he.Type != reflect.TypeFor[hookItem]() {
+			t.Errorf("unexpected error: path=%q hook=%s type=%v", he.Path, he.Hook, he.Type)
+		}
+		if !strings.Contains(err.Error(), `Groups["g"].Items[1]`) || errors.Unwrap(err).Error() != "invalid item" {
+			t.Errorf("error message: %v", err)
+		}
+
+		// 经接口到达同样带路径；CopyInto 与并行拷贝也一样
+		src = &hookRoot{Any: []hookItem{{"ok", 1}, {"bad", 2}}}
+		_, err = New().Clone(src)
+		if !errors.As(err, &he) || he.Path != ".Any[1]" {
+			t.Errorf("path through interface: %v", err)
+		}
+		items := make([]hookItem, 64)
+		items[40].Name = "bad"
+		err = New().SetParallel(4, 8).CopyInto(&[]hookItem{}, &items)
+		if !errors.As(err, &he) || he.Path != "[40]" {
+			t.Errorf("parallel CopyInto path: %v", err)
+		}
+
+		_, err = New().Clone(&hookItem{Name: "bad"})
+		if !errors.As(err, &he) || he.Path != "" || !strings.Contains(err.Error(), "<root>") {
+			t.Errorf("root error: %v", err)
+		}
+
+		// JSON 树走快速路径，路径同样逐层记录
+		type jsonHolder struct{ M map[string]any }
+		doc := map[string]any{"k": []any{1.0, hookItem{Name: "bad"}}}
+		for _, c := range []*Copier{New(), New().SetParallel(4, 1)} {
+			_, err = c.Clone(&jsonHolder{M: doc})
+			if !errors.As(err, &he) || he.Path != `.M["k"][1]` {
+				t.Errorf("path through JSON tree: %v", err)
+			}
+			_, err = c.Clone(doc)
+			if !errors.As(err, &he) || he.Path != `["k"][1]` || !strings.Contains(err.Error(), `at ["k"][1]`) {
+				t.Errorf("path through JSON root: %v", err)
+			}
+		}
+	})
+}
+
+// ============================================================================
+// 序列化回退
+// ============================================================================
+
+// opaqueBinary、opaqueGob、opaqueText 模拟只能经编解码方法访问内部状态的第三方类型
+type opaqueBinary struct {
+	secret []byte
+	fail   bool
+}
+
+var opaqueBinaryCalls atomic.Int32
+
+func (o opaqueBinary) MarshalBinary() ([]byte, error) {
+	opaqueBinaryCalls.Add(1)
+	if o.fail {
+		return nil, fmt.Errorf("cannot marshal")
+	}
+	return append([]byte(nil), o.secret...), nil
+}
+
+func (o *opaqueBinary) UnmarshalBinary(data []byte) error {
+	o.secret = append([]byte(nil), data...)
+	return nil
+}
+
+type opaqueGob struct {
+	n int
+}
+
+func (o *opaqueGob) GobEncode() ([]byte, error) { return []byte{byte(o.n)}, nil }
+
+func (o *opaqueGob) GobDecode(data []byte) error {
+	o.n = int(data[0])
+	return nil
+}
+
+type opaqueText struct {
+	s string
+}
+
+func (o opaqueText) MarshalText() ([]byte, error) { return []byte(o.s), nil }
+
+func (o *opaqueText) UnmarshalText(data []byte) error {
+	o.s = string(data)
+	return nil
+}
+
+// opaquePlain 没有编解码方法，回退不适用
+type opaquePlain struct {
+	v int
+}
+
+type opaqueHolder struct {
+	B  opaqueBinary
+	PB *opaqueBinary
+	G  opaqueGob
+	T  []opaqueText
+	P  opaquePlain
+	At time.Time
+}
+
+func TestMarshalFallback(t *testing.T) {
+	at := time.Date(2024, 5, 6, 7, 8, 9, 10, time.UTC)
+	makeSrc := func() *opaqueHolder {
+		return &opaqueHolder{
+			B:  opaqueBinary{secret: []byte("s")},
+			PB: &opaqueBinary{secret: []byte("p")},
+			G:  opaqueGob{n: 7},
+			T:  []opaqueText{{"x"}, {"y"}},
+			P:  opaquePlain{v: 1},
+			At: at,
+		}
+	}
+
+	t.Run("global", func(t *testing.T) {
+		src := makeSrc()
+		got, err := New().SetMarshalFallback(true).SetStrict(false).Clone(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := got.(*opaqueHolder)
+		if string(dst.B.secret) != "s" || string(dst.PB.secret) != "p" || dst.G.n != 7 || dst.T[1].s != "y" {
+			t.Fatalf("opaque state not cloned: %+v", dst)
+		}
+		if &dst.B.secret[0] == &src.B.secret[0] || dst.PB == src.PB {
+			t.Error("fallback copy shares memory with src")
+		}
+		if dst.At != at {
+			t.Errorf("time.Time = %v, want %v", dst.At, at)
+		}
+		if dst.P.v != 0 {
+			t.Error("types without codecs keep the default behavior")
+		}
+	})
+
+	t.Run("time_keeps_location", func(t *testing.T) {
+		// MarshalBinary 只编码时区偏移，time.Time 按值拷贝，具名时区保持共享
+		loc, err := time.LoadLocation("America/New_York")
+		if err != nil {
+			loc = time.FixedZone("EST", -5*3600)
+		}
+		src := &opaqueHolder{At: time.Date(2024, 1, 2, 3, 4, 5, 6, loc)}
+		for _, c := range []*Copier{
+			New().SetMarshalFallback(true),
+			New().MarshalFallback(reflect.TypeFor[time.Time]()),
+		} {
+			got, err := c.Clone(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dst := got.(*opaqueHolder)
+			if dst.At != src.At || dst.At.Location() != loc {
+				t.Errorf("location not preserved: %v (%p) vs %v", dst.At, dst.At.Location(), src.At)
+			}
+			if name, _ := dst.At.Add(180 * 24 * time.Hour).Zone(); loc.String() == "America/New_York" && name != "EDT" {
+				t.Errorf("DST rules lost: zone %q", name)
+			}
+		}
+
+		now := time.Now()
+		var dst time.Time
+		if err := New().SetMarshalFallback(true).Copy(&dst, now); err != nil {
+			t.Fatal(err)
+		}
+		if dst != now {
+			t.Error("monotonic reading or Local not preserved")
+		}
+	})
+
+	t.Run("per_type", func(t *testing.T) {
+		got, err := New().MarshalFallback(reflect.TypeFor[opaqueGob]()).Clone(makeSrc())
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := got.(*opaqueHolder)
+		if dst.G.n != 7 || dst.B.secret != nil {
+			t.Error("only the listed type should use the fallback")
+		}
+
+		defer func() {
+			if recover() == nil {
+				t.Error("MarshalFallback on a type without codecs should panic")
+			}
+		}()
+		New().MarshalFallback(reflect.TypeFor[opaquePlain]())
+	})
+
+	t.Run("unexported_policy", func(t *testing.T) {
+		// 允许拷贝未导出字段时按字段拷贝；被拒绝的包重新走回退
+		opaqueBinaryCalls.Store(0)
+		c := New().SetCopyUnexported(true).SetMarshalFallback(true)
+		got, err := c.Clone(makeSrc())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opaqueBinaryCalls.Load() != 0 || got.(*opaqueHolder).P.v != 1 {
+			t.Error("fallback should not apply when unexported fields may be copied")
+		}
+
+		c = New().SetCopyUnexported(true).SetMarshalFallback(true).DenyUnexported("github.com/shuhan-0/deepcopy")
+		got, err = c.Clone(makeSrc())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opaqueBinaryCalls.Load() == 0 || string(got.(*opaqueHolder).B.secret) != "s" {
+			t.Error("fallback should apply to denied packages")
+		}
+	})
+
+	t.Run("copy_into_and_errors", func(t *testing.T) {
+		c := New().SetMarshalFallback(true)
+		dst := &opaqueHolder{B: opaqueBinary{secret: []byte("old")}, T: []opaqueText{{"old"}}}
+		if err := c.CopyInto(dst, makeSrc()); err != nil {
+			t.Fatal(err)
+		}
+		if string(dst.B.secret) != "s" || dst.T[0].s != "x" {
+			t.Error("CopyInto through the fallback")
+		}
+
+		_, err := c.Clone(&opaqueHolder{B: opaqueBinary{fail: true}})
+		if err == nil || !strings.Contains(err.Error(), "cannot marshal") {
+			t.Errorf("marshal error should propagate, got %v", err)
+		}
+		if c.loadPlans() == New().loadPlans() {
+			t.Error("SetMarshalFallback must change the plan fingerprint")
+		}
+	})
+}
@@ -0,0 +1,186 @@
+package deepcopy
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// BeforeCopier 结构体的 *T 实现它时，拷贝其字段之前先在 src 上调用，
+// 用于把惰性计算的字段落实到字段里
+type BeforeCopier interface {
+	DeepCopyBefore()
+}
+
+// AfterCopier 结构体的 *T 实现它时，字段拷贝完成后在拷贝结果上调用，
+// src 为原对象的 *T，用于重建索引、重置缓存、分配新 ID 等派生状态
+type AfterCopier interface {
+	DeepCopyAfter(src any) error
+}
+
+// Validator 结构体的 *T 实现它时，在 DeepCopyAfter 之后校验拷贝结果
+type Validator interface {
+	Validate() error
+}
+
+// hookSet 结构体实现的钩子，编译期确定
+type hookSet uint8
+
+const (
+	hookBefore hookSet = 1 << iota
+	hookAfter
+	hookValidate
+)
+
+var (
+	beforeCopierType = reflect.TypeFor[BeforeCopier]()
+	afterCopierType  = reflect.TypeFor[AfterCopier]()
+	validatorType    = reflect.TypeFor[Validator]()
+)
+
+func hooksOf(t reflect.Type) hookSet {
+	pt := reflect.PointerTo(t)
+	var hs hookSet
+	if pt.Implements(beforeCopierType) {
+		hs |= hookBefore
+	}
+	if pt.Implements(afterCopierType) {
+		hs |= hookAfter
+	}
+	if pt.Implements(validatorType) {
+		hs |= hookValidate
+	}
+	return hs
+}
+
+// analyzeHooks 标记可能执行钩子的计划：自身有钩子、动态类型未知的接口，
+// 或子计划满足上述条件。只有这些计划在拷贝时记录字段路径
+func analyzeHooks(order []*typeCopier) {
+	for _, tc := range order {
+		tc.hooked = tc.hk != 0 || tc.kind == kindInterface
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, tc := range order {
+			if !tc.hooked && tc.anyChild(func(child *typeCopier) bool { return child.hooked }) {
+				tc.hooked = true
+				changed = true
+			}
+		}
+	}
+}
+
+// HookError DeepCopyAfter 或 Validate 返回的错误，Path 为出错对象在拷贝根对象中的位置
+type HookError struct {
+	Path string       // 如 "Users[3].Profile"，根对象本身为空
+	Type reflect.Type // 出错的结构体类型
+	Hook string       // "DeepCopyAfter" 或 "Validate"
+	Err  error
+}
+
+func (e *HookError) Error() string {
+	path := strings.TrimPrefix(e.Path, ".")
+	if path == "" {
+		path = "<root>"
+	}
+	return fmt.Sprintf("deepcopy: %s of %v at %s: %v", e.Hook, e.Type, path, e.Err)
+}
+
+func (e *HookError) Unwrap() error { return e.Err }
+
+// fillStructHooked 带钩子或路径记录的 fillStruct：
+// src 上调用 DeepCopyBefore，逐字段拷贝，再在 dst 上调用 DeepCopyAfter 与 Validate
+func (tc *typeCopier) fillStructHooked(dst, src reflect.Value, into bool, st *copyState) {
+	if tc.hk&hookBefore != 0 {
+		if !src.CanAddr() {
+			src = addressable(src) // 钩子作用在临时变量上，随后从它拷贝
+		}
+		reflect.NewAt(tc.typ, srcAddr(src)).Interface().(BeforeCopier).DeepCopyBefore()
+	}
+
+	cur := int32(-1)
+	func() {
+		defer traceField(tc.typ, &cur)
+		if into {
+			tc.copyFieldsInto(dst, src, st, &cur)
+		} else {
+			tc.fillFields(dst, src, st, &cur)
+		}
+	}()
+
+	if tc.hk&(hookAfter|hookValidate) == 0 {
+		return
+	}
+	obj := reflect.NewAt(tc.typ, srcAddr(dst)).Interface()
+	if tc.hk&hookAfter != 0 {
+		srcObj := reflect.NewAt(tc.typ, srcAddr(src)).Interface()
+		if err := obj.(AfterCopier).DeepCopyAfter(srcObj); err != nil {
+			panic(copyError{&HookError{Type: tc.typ, Hook: "DeepCopyAfter", Err: err}})
+		}
+	}
+	if tc.hk&hookValidate != 0 {
+		if err := obj.(Validator).Validate(); err != nil {
+			panic(copyError{&HookError{Type: tc.typ, Hook: "Validate", Err: err}})
+		}
+	}
+}
+
+// traceField/traceIndex/traceKey 钩子错误向上穿过一层容器时，在路径前补上当前位置；
+// 位置只在出错时格式化
+func traceField(t reflect.Type, cur *int32) {
+	if r := recover(); r != nil {
+		if he := hookErrorOf(r); he != nil && *cur >= 0 {
+			he.Path = "." + t.Field(int(*cur)).Name + he.Path
+		}
+		panic(r)
+	}
+}
+
+func traceIndex(cur *int) {
+	if r := recover(); r != nil {
+		if he := hookErrorOf(r); he != nil {
+			he.Path = fmt.Sprintf("[%d]", *cur) + he.Path
+		}
+		panic(r)
+	}
+}
+
+func traceKey(cur *reflect.Value) {
+	if r := recover(); r != nil {
+		if he := hookErrorOf(r); he != nil && cur.IsValid() {
+			he.Path = fmt.Sprintf("[%#v]", *cur) + he.Path
+		}
+		panic(r)
+	}
+}
+
+// traceName 同 traceKey，用于 JSON 快速路径中 map[string]any 的 key
+func traceName(cur *string) {
+	if r := recover(); r != nil {
+		if he := hookErrorOf(r); he != nil {
+			he.Path = fmt.Sprintf("[%q]", *cur) + he.Path
+		}
+		panic(r)
+	}
+}
+
+func hookErrorOf(r any) *HookError {
+	if ce, ok := r.(copyError); ok {
+		he, _ := ce.err.(*HookError)
+		return he
+	}
+	return nil
+}
+
+// copyElemRangeHooked 同 copyElemRange / copyElemRangeInto，记录当前下标
+func (tc *typeCopier) copyElemRangeHooked(dst, src reflect.Value, lo, hi int, into bool, st *copyState) {
+	i := lo
+	defer traceIndex(&i)
+	for ; i < hi; i++ {
+		if into {
+			tc.elem.copyInto(dst.Index(i), src.Index(i), st)
+		} else {
+			tc.elem.copyAt(dst.Index(i), src.Index(i), st)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package deepcopy
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// 内置的身份敏感类型：值的含义依赖指针身份，深拷贝后 == 比较、
+// Local 判断和各类按指针的缓存查找都会失效，因此始终按引用共享
+var (
+	rtypePtrType     = reflect.TypeOf(reflect.TypeOf(0)) // reflect.Type 的动态类型 *reflect.rtype
+	reflectTypeType  = reflect.TypeFor[reflect.Type]()
+	reflectValueType = reflect.TypeFor[reflect.Value]()
+	locationPtrType  = reflect.TypeFor[*time.Location]()
+)
+
+// isIdentityType 判断 t 是否为内置的身份敏感类型
+func isIdentityType(t reflect.Type) bool {
+	switch t {
+	case rtypePtrType, reflectTypeType, reflectValueType, locationPtrType:
+		return true
+	}
+	// unique.Handle[T] 为泛型，按包名与类型名前缀识别所有实例化（netip.Addr 的 zone 即为此类）
+	return t.Kind() == reflect.Struct && t.PkgPath() == "unique" && strings.HasPrefix(t.Name(), "Handle[")
+}
+
+// ShareIdentity 追加按引用共享的类型：这些类型的值原样放进拷贝结果，不再深入其内部。
+// 适用于依赖指针身份的句柄、驻留对象、全局注册表项等。
+// 内置已包含 reflect.Type、reflect.Value、*time.Location 与 unique.Handle[T]。
+func (c *Copier) ShareIdentity(types ...reflect.Type) *Copier {
+	for _, t := range types {
+		if t == nil {
+			panic("deepcopy: ShareIdentity: nil type")
+		}
+	}
+	c.opts.shared = append(slices.Clip(c.opts.shared), types...)
+	c.rebind()
+	return c
+}
+
+// ShareImplementationsOf 按接口声明共享：静态类型或接口里的动态类型实现了
+// 其中任一接口的值按引用共享，适用于 context.Context、net.Conn、io.Closer 这类资源句柄。
+// 判断在编译该类型的计划时进行一次，拷贝时不再逐值检查。
+// 静态类型本身是实现了这些接口的接口类型（如 net.Conn 之于 io.Closer）时，整个接口值共享。
+func (c *Copier) ShareImplementationsOf(ifaces ...reflect.Type) *Copier {
+	for _, t := range ifaces {
+		if t == nil || t.Kind() != reflect.Interface {
+			panic(fmt.Sprintf("deepcopy: ShareImplementationsOf: %v is not an interface type", t))
+		}
+		if t.NumMethod() == 0 {
+			panic(fmt.Sprintf("deepcopy: ShareImplementationsOf: %v would share every value", t))
+		}
+	}
+	c.opts.sharedIfaces = append(slices.Clip(c.opts.sharedIfaces), ifaces...)
+	c.rebind()
+	return c
+}
+
+// shares 判断 t 的值是否按引用共享
+func (o *planOptions) shares(t reflect.Type) bool {
+	if isIdentityType(t) {
+		return true
+	}
+	for _, s := range o.shared {
+		if s == t {
+			return true
+		}
+	}
+	for _, i := range o.sharedIfaces {
+		if t.Implements(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeTypes 类型以 *rtype 地址编码：进程内唯一，注册表也只在进程内有效
+func writeTypes(sb *strings.Builder, tag string, types []reflect.Type) {
+	if len(types) == 0 {
+		return
+	}
+	sb.WriteString(tag)
+	for _, t := range types {
+		fmt.Fprintf(sb, ":%x", uintptr(rtypeOf(t)))
+	}
+}
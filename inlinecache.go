@@ -0,0 +1,50 @@
+package deepcopy
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// ifaceCacheSize 多态内联缓存容量；装满后视为超多态，不再更新
+const ifaceCacheSize = 8
+
+// ifaceCache 接口计划上的内联缓存：动态类型 -> 计划。
+// 不可变对象，更新时整体替换（CAS），读路径只有一次原子加载和最多 8 次指针比较。
+type ifaceCache struct {
+	n       int
+	entries [ifaceCacheSize]ifaceEntry
+}
+
+type ifaceEntry struct {
+	rtype unsafe.Pointer
+	tc    *typeCopier
+}
+
+// dynamicCopier 返回接口动态类型 t 的计划。
+// 同质集合（[]any、map[string]any 中元素类型相同）命中缓存后不再访问全局缓存，
+// HighVolume 模式下也就不再加读锁。
+func (tc *typeCopier) dynamicCopier(t reflect.Type, st *copyState) *typeCopier {
+	rt := rtypeOf(t)
+	ic := tc.ic.Load()
+	if ic != nil {
+		for i := 0; i < ic.n; i++ {
+			if ic.entries[i].rtype == rt {
+				return ic.entries[i].tc
+			}
+		}
+		if ic.n == ifaceCacheSize {
+			return st.plans.getTypeCopier(t) // 超多态
+		}
+	}
+
+	actual := st.plans.getTypeCopier(t)
+
+	next := &ifaceCache{}
+	if ic != nil {
+		*next = *ic
+	}
+	next.entries[next.n] = ifaceEntry{rtype: rt, tc: actual}
+	next.n++
+	tc.ic.CompareAndSwap(ic, next) // 竞争失败无妨，下次再填
+	return actual
+}
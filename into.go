@@ -0,0 +1,348 @@
+package deepcopy
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// CopyInto 把 src 深拷贝进 dst，尽量复用 dst 已持有的内存：
+// 容量足够的切片原地覆盖，已有的 map 清空后重新填充，非 nil 指针直接写入其指向的对象。
+// 每帧把同一份状态拷进同一个缓冲区时，稳态下几乎不再分配。
+//
+// 计划之外的字段（未开启 copyUnexported 时的未导出字段等）保留 dst 原值。
+// dst 与 src 不应共享可变子对象；指向同一对象的位置会改为新分配。
+// 开启循环检测时，dst 内部被多处共享的目标只会被复用一次，
+// 不会把两份不同的数据写进同一个对象。
+func (c *Copier) CopyInto(dst, src interface{}) error {
+	return c.copyIntoRoot(dst, src, false)
+}
+
+// copyIntoRoot CopyInto 与 Restore 共用的入口：restore 为 true 时 map 按 key 对齐
+func (c *Copier) copyIntoRoot(dst, src interface{}, restore bool) (err error) {
+	if dst == nil || src == nil {
+		return fmt.Errorf("dst and src must be non-nil")
+	}
+
+	dstVal := reflect.ValueOf(dst)
+	if dstVal.Kind() != reflect.Ptr || dstVal.IsNil() {
+		return fmt.Errorf("dst must be a non-nil pointer, got %T", dst)
+	}
+	dstElem := dstVal.Elem()
+
+	srcVal := reflect.ValueOf(src)
+	srcElem := srcVal
+	if srcVal.Kind() == reflect.Ptr {
+		if srcVal.IsNil() {
+			dstElem.SetZero()
+			return nil
+		}
+		srcElem = srcVal.Elem()
+	}
+
+	if srcElem.Type() != dstElem.Type() {
+		return fmt.Errorf("type mismatch: src=%v, dst=%v", srcElem.Type(), dstElem.Type())
+	}
+
+	tc := c.getTypeCopier(srcElem.Type())
+	if tc.err != nil {
+		return tc.err
+	}
+	if tc.podValue() {
+		copyPOD(dstElem, srcElem, tc.typ.Size())
+		return nil
+	}
+	if srcVal.Kind() == reflect.Ptr && srcVal.Pointer() == dstVal.Pointer() {
+		return nil // 拷给自己
+	}
+
+	st := c.newCopyState(tc)
+	st.restore = restore
+	if st.visited != nil {
+		defer releaseVisitTable(st.visited)
+		st.owned = acquireVisitTable()
+		st.owned.shared = st.visited.shared
+		defer releaseVisitTable(st.owned)
+
+		// 根对象本身也登记：src 图中指回根的引用在 dst 中指回 dst
+		if srcVal.Kind() == reflect.Ptr {
+			ptc := c.getTypeCopier(srcVal.Type())
+			st.visited.insert(srcVal.Pointer(), ptc.rtype, dstVal.UnsafePointer())
+			st.owned.insert(dstVal.Pointer(), ptc.rtype, nil)
+		}
+	}
+
+	defer recoverCopyError(&err)
+	unlock := st.lockRoot(tc, srcVal)
+	defer unlock()
+	tc.copyInto(dstElem, srcElem, &st)
+	unlock()
+	st.drainLocks()
+	st.resolveWeak()
+	return nil
+}
+
+// copyInto 把 src 深拷贝进可寻址的 dst，复用 dst 现有的切片、map 与指针目标
+func (tc *typeCopier) copyInto(dst, src reflect.Value, st *copyState) {
+	switch tc.kind {
+	case kindPtr:
+		dst.Set(tc.ptrInto(dst, src, st))
+	case kindSlice:
+		tc.copySliceInto(dst, src, st)
+	case kindArray:
+		tc.copyArrayInto(dst, src, st)
+	case kindMap:
+		tc.copyMapInto(dst, src, st)
+	case kindStruct:
+		tc.copyStructInto(dst, src, st)
+	case kindInterface:
+		tc.copyInterfaceInto(dst, src, st)
+	case kindSyncMap:
+		tc.copySyncMapInto(dst, src, st)
+	case kindAtomic:
+		tc.copyAtomicInto(dst, src, st)
+	case kindBuiltin:
+		tc.copyBuiltinInto(dst, src, st)
+	case kindZero:
+		dst.SetZero()
+	case kindSkip:
+		// 保留 dst 原值
+	default:
+		dst.Set(tc.copy(src, st))
+	}
+}
+
+// reuse 判断能否写入 dst 现有的目标对象 cur：不能与 src 是同一对象；
+// 开启身份跟踪时同一目标只允许被占用一次，dst 内部的共享不会被写串
+func (st *copyState) reuse(cur uintptr, typ unsafe.Pointer, src uintptr) bool {
+	if cur == 0 || cur == src {
+		return false
+	}
+	t := st.owned
+	if t == nil {
+		return true
+	}
+	t.lock()
+	defer t.unlock()
+	if _, ok := t.lookup(cur, typ); ok {
+		return false
+	}
+	t.insert(cur, typ, nil)
+	return true
+}
+
+// claimInto 在身份表中查找 src：命中时返回已有目标；
+// 未命中且 cur 可复用时登记 src -> at 并返回 reused，调用方负责填充；
+// 两者皆否时调用方回退到普通拷贝
+func (st *copyState) claimInto(src uintptr, tc *typeCopier, cur uintptr, at unsafe.Pointer) (hit unsafe.Pointer, reused bool) {
+	t := st.visited
+	if t == nil {
+		return nil, st.reuse(cur, tc.rtype, src)
+	}
+	t.lock()
+	defer t.unlock()
+	if p, ok := t.lookup(src, tc.rtype); ok {
+		return p, false
+	}
+	if !st.reuse(cur, tc.rtype, src) {
+		return nil, false
+	}
+	t.insert(src, tc.rtype, at)
+	return nil, true
+}
+
+// ptrInto 返回 src 的拷贝，尽量复用 cur 指向的现有对象（cur 无需可寻址）
+func (tc *typeCopier) ptrInto(cur, src reflect.Value, st *copyState) reflect.Value {
+	if src.IsNil() {
+		return reflect.Zero(tc.typ)
+	}
+	var p unsafe.Pointer
+	if !cur.IsNil() {
+		p = cur.UnsafePointer()
+	}
+	hit, reused := st.claimInto(src.Pointer(), tc, uintptr(p), p)
+	if hit != nil {
+		return reflect.NewAt(tc.typ.Elem(), hit)
+	}
+	if !reused {
+		return tc.copyPtr(src, st)
+	}
+	if st.locks != nil && tc.elem.lk != lockNone {
+		tc.fillLocked(cur, src, true, st)
+		return cur
+	}
+	tc.elem.copyInto(cur.Elem(), src.Elem(), st)
+	return cur
+}
+
+func (tc *typeCopier) copySliceInto(dst, src reflect.Value, st *copyState) {
+	if src.IsNil() {
+		dst.SetZero()
+		return
+	}
+
+	n := src.Len()
+	if !dst.IsNil() && dst.Cap() >= n &&
+		(n == 0 || st.reuse(uintptr(dst.UnsafePointer()), tc.rtype, uintptr(src.UnsafePointer()))) {
+		old := dst.Len()
+		dst.SetLen(n)
+		if old > n {
+			dst.Slice(n, old).Clear() // 截掉的尾部不再持有旧引用
+		}
+	} else {
+		grown := reflect.MakeSlice(tc.typ, n, src.Cap())
+		if st.restore && !dst.IsNil() {
+			// 容量不足时换新底层数组，但保留原有元素，其中的指针目标照常按位置复用
+			reflect.Copy(grown, dst)
+		}
+		dst.Set(grown)
+	}
+
+	if tc.isPOD {
+		reflect.Copy(dst, src)
+		return
+	}
+	tc.copyElemsInto(dst, src, n, st)
+}
+
+func (tc *typeCopier) copyArrayInto(dst, src reflect.Value, st *copyState) {
+	if tc.isPOD {
+		copyPOD(dst, src, tc.typ.Size())
+		return
+	}
+	tc.copyElemsInto(dst, src, int(tc.arrayLen), st)
+}
+
+// copyElemsInto 与 copyElems 相同的分段方式，逐元素原地拷贝
+func (tc *typeCopier) copyElemsInto(dst, src reflect.Value, n int, st *copyState) {
+	if st.par != nil && n >= st.par.threshold {
+		st.par.run(n, st, func(lo, hi int, st *copyState) {
+			tc.copyElemRangeInto(dst, src, lo, hi, st)
+		})
+		return
+	}
+	tc.copyElemRangeInto(dst, src, 0, n, st)
+}
+
+func (tc *typeCopier) copyElemRangeInto(dst, src reflect.Value, lo, hi int, st *copyState) {
+	if tc.elem.hooked {
+		tc.copyElemRangeHooked(dst, src, lo, hi, true, st)
+		return
+	}
+	for i := lo; i < hi; i++ {
+		tc.elem.copyInto(dst.Index(i), src.Index(i), st)
+	}
+}
+
+func (tc *typeCopier) copyMapInto(dst, src reflect.Value, st *copyState) {
+	if src.IsNil() {
+		dst.SetZero()
+		return
+	}
+	var cur uintptr
+	if !dst.IsNil() {
+		cur = dst.Pointer()
+	}
+
+	// dst 本身可寻址，直接登记它的地址，无需额外的堆变量
+	hit, reused := st.claimInto(src.Pointer(), tc, cur, unsafe.Pointer(dst.UnsafeAddr()))
+	if hit != nil {
+		dst.Set(reflect.NewAt(tc.typ, hit).Elem())
+		return
+	}
+	if !reused {
+		dst.Set(tc.copyMap(src, st))
+		return
+	}
+	if st.restore {
+		tc.restoreMap(dst, src, st)
+		return
+	}
+	dst.Clear()
+	if st.par != nil && src.Len() >= st.par.threshold {
+		tc.fillMapParallel(dst, src, st)
+		return
+	}
+	// 稳态下反复写入同一批 map，临时变量跨 map 复用
+	k, v := st.takeMapTemps(tc)
+	tc.fillMapEntries(dst, src, k, v, st)
+	st.putMapTemps(tc, k, v)
+}
+
+func (tc *typeCopier) copyStructInto(dst, src reflect.Value, st *copyState) {
+	if tc.hooked {
+		tc.fillStructHooked(dst, src, true, st)
+		return
+	}
+	tc.copyFieldsInto(dst, src, st, nil)
+}
+
+// copyFieldsInto copyStructInto 的字段拷贝部分；cur 非 nil 时记录正在拷贝的字段下标
+func (tc *typeCopier) copyFieldsInto(dst, src reflect.Value, st *copyState, cur *int32) {
+	if tc.fields == nil || len(*tc.fields) == 0 {
+		return
+	}
+	if tc.isPOD {
+		copyPOD(dst, src, tc.typ.Size())
+		return
+	}
+
+	if tc.needsAddr && !src.CanAddr() {
+		src = addressable(src)
+	}
+	srcCanAddr := src.CanAddr()
+	dstBase := unsafe.Pointer(dst.UnsafeAddr())
+
+	for i := range *tc.fields {
+		fc := &(*tc.fields)[i]
+		if cur != nil {
+			*cur = fc.index
+		}
+		dstPtr := unsafe.Add(dstBase, fc.offset)
+
+		if fc.copier.podValue() && srcCanAddr {
+			runtimeMemmove(dstPtr, unsafe.Add(unsafe.Pointer(src.UnsafeAddr()), fc.offset), fc.fieldType.Size())
+			continue
+		}
+
+		var srcField reflect.Value
+		if fc.canSet {
+			srcField = src.Field(int(fc.index))
+		} else if srcCanAddr {
+			srcField = reflect.NewAt(fc.fieldType, unsafe.Add(unsafe.Pointer(src.UnsafeAddr()), fc.offset)).Elem()
+		} else {
+			continue
+		}
+		// 目标字段经 NewAt 取得，未导出字段同样可写
+		fc.copier.copyInto(reflect.NewAt(fc.fieldType, dstPtr).Elem(), srcField, st)
+	}
+}
+
+// copyInterfaceInto 动态类型相同且为指针或 map 时写入现有目标，其余情况整体替换
+func (tc *typeCopier) copyInterfaceInto(dst, src reflect.Value, st *copyState) {
+	if src.IsNil() {
+		dst.SetZero()
+		return
+	}
+	actual := src.Elem()
+	if dst.IsNil() || dst.Elem().Type() != actual.Type() {
+		tc.copyInterfaceAt(dst, src, st)
+		return
+	}
+
+	ac := tc.dynamicCopier(actual.Type(), st)
+	if ac.err != nil {
+		panic(copyError{ac.err})
+	}
+	switch ac.kind {
+	case kindPtr:
+		dst.Set(ac.ptrInto(dst.Elem(), actual, st))
+	case kindMap:
+		cell := reflect.New(ac.typ).Elem()
+		cell.Set(dst.Elem())
+		ac.copyMapInto(cell, actual, st)
+		dst.Set(cell)
+	default:
+		tc.copyInterfaceAt(dst, src, st)
+	}
+}
@@ -0,0 +1,131 @@
+package deepcopy
+
+import (
+	"encoding/json"
+	"reflect"
+	"unsafe"
+)
+
+// JSON 解码结果的两种容器类型
+var (
+	jsonObjectType = reflect.TypeFor[map[string]any]()
+	jsonArrayType  = reflect.TypeFor[[]any]()
+	jsonObjectRT   = rtypeOf(jsonObjectType)
+)
+
+// jsonCloner 针对 encoding/json 解码结果的闭集类型
+// （map[string]any、[]any、string、float64、bool、json.Number、nil）
+// 的无反射克隆。闭集之外的值交回通用路径，因此混入其他类型也能正确拷贝。
+type jsonCloner struct {
+	st  *copyState
+	any *typeCopier // interface{} 的计划，用于闭集之外的动态类型
+
+	// held 上一个闭集之外的值内含待回填的弱指针时，回填写到的接口变量（见 copyInterface），
+	// 调用方写入容器后据此登记；mark 为拷贝它之前的 weakMark
+	held reflect.Value
+	mark int32
+}
+
+func (j *jsonCloner) value(v any) any {
+	switch x := v.(type) {
+	case nil, string, float64, bool, json.Number:
+		return v // 不可变值，直接共享
+	case map[string]any:
+		return j.object(x)
+	case []any:
+		return j.array(x)
+	}
+	mark := j.st.weakMark()
+	out := j.any.copyInterface(reflect.ValueOf(&v).Elem(), j.st)
+	if j.st.weakMark() != mark {
+		j.held, j.mark = out, mark
+	}
+	return out.Interface()
+}
+
+func (j *jsonCloner) object(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+
+	// 与 copyMap 共用身份表登记格式：登记一个存放 map 的堆变量地址
+	if t := j.st.visited; t != nil {
+		key := uintptr(*(*unsafe.Pointer)(unsafe.Pointer(&m)))
+		t.lock()
+		if p, ok := t.lookup(key, jsonObjectRT); ok {
+			t.unlock()
+			return *(*map[string]any)(p)
+		}
+		cell := new(map[string]any)
+		*cell = make(map[string]any, len(m))
+		t.insert(key, jsonObjectRT, unsafe.Pointer(cell))
+		t.unlock()
+		j.fill(*cell, m)
+		return *cell
+	}
+
+	dst := make(map[string]any, len(m))
+	j.fill(dst, m)
+	return dst
+}
+
+func (j *jsonCloner) fill(dst, src map[string]any) {
+	var key string
+	if j.any.hooked {
+		defer traceName(&key)
+	}
+	for k, v := range src {
+		key = k
+		dst[k] = j.value(v)
+		if j.held.IsValid() {
+			// 回填写在 held 上，事后重新写入 dst[k]
+			t := j.st.visited
+			t.lock()
+			t.weakEntries = append(t.weakEntries, weakEntry{m: reflect.ValueOf(dst), k: reflect.ValueOf(k), v: j.held})
+			t.unlock()
+			j.held = reflect.Value{}
+		}
+	}
+}
+
+// array 与 copySlice 一致：保留 cap，切片本身不参与身份跟踪
+func (j *jsonCloner) array(a []any) []any {
+	if a == nil {
+		return nil
+	}
+	dst := make([]any, len(a), cap(a))
+	if j.st.par != nil && len(a) >= j.st.par.threshold {
+		anyTC := j.any // 闭包只捕获字段值，j 本身留在调用方栈上
+		j.st.par.run(len(a), j.st, func(lo, hi int, st *copyState) {
+			w := jsonCloner{st: st, any: anyTC}
+			i := lo
+			if anyTC.hooked {
+				defer traceIndex(&i)
+			}
+			for ; i < hi; i++ {
+				dst[i] = w.value(a[i])
+				w.settle(&dst[i])
+			}
+		})
+		return dst
+	}
+	i := 0
+	if j.any.hooked {
+		defer traceIndex(&i)
+	}
+	for ; i < len(a); i++ {
+		dst[i] = j.value(a[i])
+		j.settle(&dst[i])
+	}
+	return dst
+}
+
+// settle 把写在 held 上的待回填槽位改到元素 p 上（指针形状的动态类型，见 boxWeak）
+func (j *jsonCloner) settle(p *any) {
+	if !j.held.IsValid() {
+		return
+	}
+	held := (*[2]unsafe.Pointer)(unsafe.Pointer(j.held.UnsafeAddr()))
+	j.st.visited.retargetWeak(j.mark, &held[1], &(*[2]unsafe.Pointer)(unsafe.Pointer(p))[1])
+	j.held = reflect.Value{}
+}
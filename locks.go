@@ -0,0 +1,149 @@
+package deepcopy
+
+import (
+	"reflect"
+	"sync"
+)
+
+// lockKind 结构体经指针到达时，锁感知模式下如何锁住它
+type lockKind uint8
+
+const (
+	lockNone  lockKind = iota
+	lockRead           // *T 实现了 RLock/RUnlock
+	lockWrite          // *T 只实现了 sync.Locker
+)
+
+type rlocker interface {
+	RLock()
+	RUnlock()
+}
+
+var rlockerType = reflect.TypeFor[rlocker]()
+
+// lockKindOf 编译期确定结构体 t 的加锁方式。同步原语本身的状态不会被拷贝，
+// 无需加锁；锁住它们反而可能与调用方已持有的锁死锁
+func lockKindOf(t reflect.Type) lockKind {
+	if isResetType(t) {
+		return lockNone
+	}
+	pt := reflect.PointerTo(t)
+	switch {
+	case pt.Implements(rlockerType):
+		return lockRead
+	case pt.Implements(lockerType):
+		return lockWrite
+	}
+	return lockNone
+}
+
+// SetLockAware 开启锁感知模式：经指针到达、*T 实现了 RLock/RUnlock（否则 Lock/Unlock）
+// 的结构体，在持有其读锁（写锁）期间拷贝其字段，包括它独占的 map、切片等，
+// 拷贝时不会与并发写入它们的 goroutine 冲突。
+//
+// 每个对象各自在自己的锁下得到一致的快照。任何时刻最多持有一把锁：
+// 持锁拷贝时遇到的另一个可加锁对象先分配好目标、登记进队列，
+// 在外层锁释放后再按发现顺序逐个加锁填充，嵌套的可加锁对象因此不会互相死锁，
+// 也不会与按其它顺序加锁的业务代码死锁。
+func (c *Copier) SetLockAware(enable bool) *Copier {
+	c.lockAware = enable
+	return c
+}
+
+// lockQueue 持锁期间发现、等待外层锁释放后再填充的可加锁对象
+type lockQueue struct {
+	mu    sync.Mutex // 并行拷贝时多个 worker 共用
+	items []lockedFill
+}
+
+// lockedFill 一个待填充的对象：tc 是指针计划，dst/src 为 *T
+type lockedFill struct {
+	tc       *typeCopier
+	dst, src reflect.Value
+	into     bool // CopyInto/Restore：写入 dst 现有对象
+}
+
+func (q *lockQueue) push(f lockedFill) {
+	q.mu.Lock()
+	q.items = append(q.items, f)
+	q.mu.Unlock()
+}
+
+func (q *lockQueue) pop() (lockedFill, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 {
+		return lockedFill{}, false
+	}
+	f := q.items[0]
+	q.items[0] = lockedFill{}
+	q.items = q.items[1:]
+	return f, true
+}
+
+// fillLocked 持有 src 指向对象的锁，把它拷进 dst 指向的对象；
+// 已持有其它对象的锁时只登记，等 drainLocks 处理
+func (tc *typeCopier) fillLocked(dst, src reflect.Value, into bool, st *copyState) {
+	if st.held {
+		st.locks.push(lockedFill{tc: tc, dst: dst, src: src, into: into})
+		return
+	}
+	// src 可能经未导出字段取得，重新构造一个不带只读标记的指针再转成接口
+	obj := reflect.NewAt(tc.typ.Elem(), src.UnsafePointer()).Interface()
+	if tc.elem.lk == lockRead {
+		obj.(rlocker).RLock()
+		defer obj.(rlocker).RUnlock()
+	} else {
+		obj.(sync.Locker).Lock()
+		defer obj.(sync.Locker).Unlock()
+	}
+	st.held = true
+	defer func() { st.held = false }()
+	if into {
+		tc.elem.copyInto(dst.Elem(), src.Elem(), st)
+	} else {
+		tc.elem.copyAt(dst.Elem(), src.Elem(), st)
+	}
+}
+
+// drainLocks 在根对象拷贝完成、不再持有任何锁时，逐个填充排队的对象
+func (st *copyState) drainLocks() {
+	if st.locks == nil {
+		return
+	}
+	for {
+		f, ok := st.locks.pop()
+		if !ok {
+			return
+		}
+		f.tc.fillLocked(f.dst, f.src, f.into, st)
+	}
+}
+
+// lockRoot 锁感知模式下，Copy/CopyInto 经指针传入的可加锁根对象同样在其锁下拷贝
+// 返回的 unlock 可重复调用：正常路径提前释放，panic 路径由 defer 兜底
+func (st *copyState) lockRoot(tc *typeCopier, srcVal reflect.Value) (unlock func()) {
+	if st.locks == nil || tc.lk == lockNone || srcVal.Kind() != reflect.Ptr {
+		return noUnlock
+	}
+	obj := reflect.NewAt(tc.typ, srcVal.UnsafePointer()).Interface()
+	if tc.lk == lockRead {
+		obj.(rlocker).RLock()
+	} else {
+		obj.(sync.Locker).Lock()
+	}
+	st.held = true
+	return func() {
+		if !st.held {
+			return
+		}
+		st.held = false
+		if tc.lk == lockRead {
+			obj.(rlocker).RUnlock()
+		} else {
+			obj.(sync.Locker).Unlock()
+		}
+	}
+}
+
+func noUnlock() {}
@@ -0,0 +1,140 @@
+package deepcopy
+
+import (
+	"encoding"
+	"encoding/gob"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+	"unsafe"
+)
+
+// SetMarshalFallback 对所有类型开启序列化回退：结构体含有不允许拷贝的未导出字段
+// （未开启 copyUnexported，或被 DenyUnexported 拒绝），且实现了成对的
+// MarshalBinary/UnmarshalBinary、GobEncode/GobDecode 或 MarshalText/UnmarshalText 时
+// （按此优先级选择），经序列化再反序列化得到拷贝，而不是丢掉未导出的状态。
+// 允许拷贝未导出字段时仍按字段深拷贝。time.Time 例外：按值拷贝并共享其 *time.Location。
+func (c *Copier) SetMarshalFallback(enable bool) *Copier {
+	c.opts.marshalAll = enable
+	c.rebind()
+	return c
+}
+
+// MarshalFallback 只对这些类型开启序列化回退，生效条件同 SetMarshalFallback。
+// 类型未实现任何一对编解码方法时 panic
+func (c *Copier) MarshalFallback(types ...reflect.Type) *Copier {
+	for _, t := range types {
+		if t == nil || marshalCodecOf(t) == nil {
+			panic(fmt.Sprintf("deepcopy: MarshalFallback: %v implements no marshal/unmarshal pair", t))
+		}
+	}
+	c.opts.marshalTypes = append(slices.Clip(c.opts.marshalTypes), types...)
+	c.rebind()
+	return c
+}
+
+// marshalCodec 一对编解码方法
+type marshalCodec struct {
+	name      string
+	marshal   func(v any) ([]byte, error)
+	unmarshal func(v any, data []byte) error
+}
+
+var (
+	binaryMarshalerType   = reflect.TypeFor[encoding.BinaryMarshaler]()
+	binaryUnmarshalerType = reflect.TypeFor[encoding.BinaryUnmarshaler]()
+	gobEncoderType        = reflect.TypeFor[gob.GobEncoder]()
+	gobDecoderType        = reflect.TypeFor[gob.GobDecoder]()
+	textMarshalerType     = reflect.TypeFor[encoding.TextMarshaler]()
+	textUnmarshalerType   = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+var marshalCodecs = []struct {
+	enc, dec reflect.Type
+	codec    marshalCodec
+}{
+	{binaryMarshalerType, binaryUnmarshalerType, marshalCodec{
+		name:      "MarshalBinary",
+		marshal:   func(v any) ([]byte, error) { return v.(encoding.BinaryMarshaler).MarshalBinary() },
+		unmarshal: func(v any, data []byte) error { return v.(encoding.BinaryUnmarshaler).UnmarshalBinary(data) },
+	}},
+	{gobEncoderType, gobDecoderType, marshalCodec{
+		name:      "GobEncode",
+		marshal:   func(v any) ([]byte, error) { return v.(gob.GobEncoder).GobEncode() },
+		unmarshal: func(v any, data []byte) error { return v.(gob.GobDecoder).GobDecode(data) },
+	}},
+	{textMarshalerType, textUnmarshalerType, marshalCodec{
+		name:      "MarshalText",
+		marshal:   func(v any) ([]byte, error) { return v.(encoding.TextMarshaler).MarshalText() },
+		unmarshal: func(v any, data []byte) error { return v.(encoding.TextUnmarshaler).UnmarshalText(data) },
+	}},
+}
+
+// marshalCodecOf 返回 t 实现的第一对编解码方法，都经 *T 调用
+func marshalCodecOf(t reflect.Type) *marshalCodec {
+	pt := reflect.PointerTo(t)
+	for i := range marshalCodecs {
+		if m := &marshalCodecs[i]; pt.Implements(m.enc) && pt.Implements(m.dec) {
+			return &m.codec
+		}
+	}
+	return nil
+}
+
+// marshalPlanOf 判断 t 是否走序列化回退：已开启回退、是含有不允许拷贝的未导出字段的结构体，
+// 且实现了某对编解码方法
+func (o *planOptions) marshalPlanOf(t reflect.Type) *builtinPlan {
+	if !o.marshalAll && !slices.Contains(o.marshalTypes, t) {
+		return nil
+	}
+	if t.Kind() != reflect.Struct || !o.dropsUnexported(t) {
+		return nil
+	}
+	if t == timeType {
+		return timePlan
+	}
+	codec := marshalCodecOf(t)
+	if codec == nil {
+		return nil
+	}
+	return &builtinPlan{fill: func(tc *typeCopier, d, s unsafe.Pointer, into bool, _ *copyState) {
+		fillMarshal(codec, tc.typ, d, s, into)
+	}}
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// timePlan time.Time 不走 MarshalBinary：编码只保留时区偏移，具名的 *time.Location
+// 会变成匿名的固定时区，== 比较与夏令时换算都会出错。time.Time 的值本身就是
+// wall/ext 两个整数加一个按引用共享的 loc，整体赋值即为正确的拷贝
+var timePlan = &builtinPlan{fill: func(_ *typeCopier, d, s unsafe.Pointer, _ bool, _ *copyState) {
+	*(*time.Time)(d) = *(*time.Time)(s)
+}}
+
+// dropsUnexported t 是否有按当前选项会被丢弃的未导出字段
+func (o *planOptions) dropsUnexported(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.PkgPath != "" {
+			if ok, _ := o.unexportedPolicy(f.PkgPath); !ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fillMarshal 把 src 编码后解码进 dst；CopyInto 时先把 dst 清零，不残留旧状态
+func fillMarshal(codec *marshalCodec, t reflect.Type, d, s unsafe.Pointer, into bool) {
+	data, err := codec.marshal(reflect.NewAt(t, s).Interface())
+	if err != nil {
+		panic(copyError{fmt.Errorf("deepcopy: %v: %s: %w", t, codec.name, err)})
+	}
+	dst := reflect.NewAt(t, d)
+	if into {
+		dst.Elem().SetZero()
+	}
+	if err := codec.unmarshal(dst.Interface(), data); err != nil {
+		panic(copyError{fmt.Errorf("deepcopy: %v: decoding %s output: %w", t, codec.name, err)})
+	}
+}
@@ -0,0 +1,116 @@
+package deepcopy
+
+import (
+	"reflect"
+	"runtime"
+	"sync"
+)
+
+// defaultParallelThreshold 元素数低于该值时分段调度的开销大于收益，保持顺序拷贝
+const defaultParallelThreshold = 4096
+
+// parallelPool 有界工作池：sem 的容量是除调用方 goroutine 之外可额外启动的 worker 数。
+// 同一 Copier 的所有并发拷贝共用一个池，嵌套的大容器也从同一个池里申请，
+// 因此总并发度不会超过 workers。
+type parallelPool struct {
+	sem       chan struct{}
+	workers   int
+	threshold int
+}
+
+// SetParallel 开启并行拷贝：元素数不少于 threshold 的 Slice/Array/Map
+// 会被切分成若干段，由最多 workers 个 goroutine 同时拷贝。
+// workers <= 0 时取 GOMAXPROCS，workers == 1 关闭并行；threshold <= 0 时使用默认值 4096。
+// 结果与顺序拷贝完全一致，循环引用与共享引用在各段之间依旧保持。
+func (c *Copier) SetParallel(workers, threshold int) *Copier {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers == 1 {
+		c.par = nil
+		return c
+	}
+	if threshold <= 0 {
+		threshold = defaultParallelThreshold
+	}
+	c.par = &parallelPool{
+		sem:       make(chan struct{}, workers-1),
+		workers:   workers,
+		threshold: threshold,
+	}
+	return c
+}
+
+// newCopyState 构造单次拷贝的上下文；并行模式下身份表与分配器由各 worker 共用，需要加锁
+func (c *Copier) newCopyState(tc *typeCopier) copyState {
+	st := copyState{plans: c.loadPlans(), par: c.par, weakPolicy: c.weakPolicy}
+	if c.alloc != nil {
+		st.alloc = c.alloc()
+		if c.par != nil {
+			st.alloc = &lockedAllocator{a: st.alloc}
+		}
+	}
+	if c.lockAware {
+		st.locks = &lockQueue{}
+	}
+	if c.handleCycle && tc.needsVisit {
+		st.visited = acquireVisitTable()
+		st.visited.shared = c.par != nil
+	}
+	return st
+}
+
+// run 把 [0, n) 切成若干段执行 fn。能立即拿到空闲 worker 的段在新 goroutine 上执行，
+// 拿不到的段由调用方就地执行，因此嵌套调用不会因为等待 worker 而死锁。
+// 任一段 panic（如严格模式的 copyError）会在所有段结束后在调用方重新抛出。
+func (p *parallelPool) run(n int, st *copyState, fn func(lo, hi int, st *copyState)) {
+	chunks := p.workers * 4 // 多切几段，让先完成的 worker 能接着领取
+	size := (n + chunks - 1) / chunks
+	if size < p.threshold/4 {
+		size = p.threshold / 4
+	}
+
+	base := *st // 拷一份再交给闭包，避免调用方的 copyState 逃逸到堆上
+	// map 临时变量属于单个 goroutine，不能随副本分给各 worker
+	base.tmpMap, base.tmpK, base.tmpV = nil, reflect.Value{}, reflect.Value{}
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		panicked any
+	)
+	exec := func(lo, hi int) {
+		defer func() {
+			if r := recover(); r != nil {
+				mu.Lock()
+				if panicked == nil {
+					panicked = r
+				}
+				mu.Unlock()
+			}
+		}()
+		wst := base // 每段独立的上下文副本，身份表与计划缓存仍然共享
+		fn(lo, hi, &wst)
+	}
+
+	for lo := 0; lo < n; lo += size {
+		hi := min(lo+size, n)
+		select {
+		case p.sem <- struct{}{}:
+			wg.Add(1)
+			go func(lo, hi int) {
+				defer func() {
+					<-p.sem
+					wg.Done()
+				}()
+				exec(lo, hi)
+			}(lo, hi)
+		default:
+			exec(lo, hi)
+		}
+	}
+	wg.Wait()
+
+	if panicked != nil {
+		panic(panicked)
+	}
+}
@@ -0,0 +1,33 @@
+package deepcopy
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Precompile 在启动阶段批量编译 types 及其可达的整张类型图，只发布一次缓存。
+// 严格模式下任一类型无法被完整拷贝时立即返回错误，便于服务在启动时暴露问题，
+// 而不是在请求路径上第一次拷贝时才失败。
+func (c *Copier) Precompile(types ...reflect.Type) error {
+	b := c.loadPlans().newPlanBuilder()
+	roots := make([]*typeCopier, 0, len(types))
+	for _, t := range types {
+		if t == nil {
+			return fmt.Errorf("deepcopy: Precompile: nil type")
+		}
+		roots = append(roots, b.resolve(t))
+	}
+	b.publish()
+
+	for _, tc := range roots {
+		if tc.err != nil {
+			return tc.err
+		}
+	}
+	return nil
+}
+
+// Warm 是 Precompile 的泛型便捷形式：Warm[Config](c)
+func Warm[T any](c *Copier) error {
+	return c.Precompile(reflect.TypeFor[T]())
+}
@@ -0,0 +1,142 @@
+package deepcopy
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// planOptions 汇总所有影响计划编译结果的选项。
+// 选项相同的 Copier 编译出的 typeCopier 图完全一致，因此可以跨实例共享；
+// 只影响拷贝过程的选项（如 handleCycle）不放在这里。
+type planOptions struct {
+	copyUnexported  bool
+	strict          bool
+	allowUnexported []string       // 包路径模式，见 AllowUnexported
+	denyUnexported  []string       // 包路径模式，见 DenyUnexported
+	shared          []reflect.Type // 按引用共享的类型，见 ShareIdentity
+	sharedIfaces    []reflect.Type // 实现即共享的接口，见 ShareImplementationsOf
+	typeRules       []typeRule     // 见 Shallow/Skip/Zero
+	fieldRules      []fieldRule    // 见 SetFieldRule
+	marshalAll      bool           // 见 SetMarshalFallback
+	marshalTypes    []reflect.Type // 见 MarshalFallback
+}
+
+// fingerprint 把选项编码成注册表的 key，新增选项时必须同步追加
+func (o *planOptions) fingerprint() string {
+	var sb strings.Builder
+	sb.WriteString("u")
+	sb.WriteString(boolFlag(o.copyUnexported))
+	sb.WriteString("s")
+	sb.WriteString(boolFlag(o.strict))
+	writePatterns(&sb, "a", o.allowUnexported)
+	writePatterns(&sb, "d", o.denyUnexported)
+	writeTypes(&sb, "i", o.shared)
+	writeTypes(&sb, "m", o.sharedIfaces)
+	writeRules(&sb, o.typeRules, o.fieldRules)
+	sb.WriteString("b")
+	sb.WriteString(boolFlag(o.marshalAll))
+	writeTypes(&sb, "t", o.marshalTypes)
+	return sb.String()
+}
+
+// writePatterns 写入带长度前缀的模式列表，任意模式内容都不会产生歧义
+func writePatterns(sb *strings.Builder, tag string, patterns []string) {
+	if len(patterns) == 0 {
+		return
+	}
+	sb.WriteString(tag)
+	sb.WriteString(strconv.Itoa(len(patterns)))
+	for _, p := range patterns {
+		sb.WriteString(":")
+		sb.WriteString(strconv.Itoa(len(p)))
+		sb.WriteString(":")
+		sb.WriteString(p)
+	}
+}
+
+func boolFlag(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// planSet 某一组编译选项下的计划缓存（COW 或 Mutex 策略）
+type planSet struct {
+	opts      planOptions
+	useCOW    bool
+	cache     atomic.Pointer[copierCache] // COW 模式使用
+	muCache   sync.RWMutex                // Mutex 模式读写 + COW 模式发布
+	mapCache  copierCache                 // Mutex 模式使用
+	cacheInit sync.Once                   // Mutex 模式延迟初始化
+}
+
+func newPlanSet(opts planOptions, useCOW bool) *planSet {
+	ps := &planSet{opts: opts, useCOW: useCOW}
+	if useCOW {
+		empty := make(copierCache, 64) // 预分配初始容量
+		ps.cache.Store(&empty)
+	}
+	return ps
+}
+
+// planKey 注册表 key：选项指纹 + 缓存策略
+type planKey struct {
+	fingerprint string
+	useCOW      bool
+}
+
+// planRegistry 进程级计划注册表：配置相同的 Copier 复用同一份编译结果，
+// 各个库各自 New() 出来的实例不会重复编译同一批类型
+var planRegistry = struct {
+	mu   sync.Mutex
+	sets map[planKey]*planSet
+}{sets: make(map[planKey]*planSet)}
+
+func sharedPlanSet(opts planOptions, useCOW bool) *planSet {
+	key := planKey{fingerprint: opts.fingerprint(), useCOW: useCOW}
+
+	planRegistry.mu.Lock()
+	defer planRegistry.mu.Unlock()
+	ps, ok := planRegistry.sets[key]
+	if !ok {
+		ps = newPlanSet(opts, useCOW)
+		planRegistry.sets[key] = ps
+	}
+	return ps
+}
+
+// rebind 选项变化后丢弃当前绑定的计划缓存，首次使用时再按最终选项绑定，
+// 链式配置的中间状态不会在注册表中留下条目。
+// 与其他 Set* 方法一样，应在 Copier 投入并发使用前调用。
+func (c *Copier) rebind() {
+	c.plans.Store(nil)
+}
+
+// loadPlans 返回当前选项对应的计划缓存，首次调用时绑定；并发的首次调用得到同一份
+func (c *Copier) loadPlans() *planSet {
+	if ps := c.plans.Load(); ps != nil {
+		return ps
+	}
+	var ps *planSet
+	if c.isolated {
+		ps = newPlanSet(c.opts, c.useCOW)
+	} else {
+		ps = sharedPlanSet(c.opts, c.useCOW)
+	}
+	if !c.plans.CompareAndSwap(nil, ps) {
+		return c.plans.Load()
+	}
+	return ps
+}
+
+// SetSharedPlans 控制是否使用进程级共享计划（默认开启）。
+// 关闭后该 Copier 使用独立缓存，不与其他实例互相影响。
+func (c *Copier) SetSharedPlans(enable bool) *Copier {
+	c.isolated = !enable
+	c.rebind()
+	return c
+}
@@ -0,0 +1,143 @@
+package deepcopy
+
+import "reflect"
+
+// Restore 把快照 snapshot 写回 live，保留 live 中各对象的身份：
+// 按编译好的计划同步遍历两张图，值写进 live 现有的指针目标、map 和切片里，
+// 外部持有的子对象指针随之看到回滚后的状态。
+//
+// 切片、数组和结构体字段按位置对齐，map 按 key 对齐：
+//   - 快照中有而 live 中没有（或为 nil）的节点新分配；
+//   - live 中有而快照中没有的节点被移除：指针置 nil、切片截短、map 删除对应 key；
+//   - 接口的动态类型与快照不同时整体替换。
+//
+// 其余规则与 CopyInto 相同。NaN key 无法按值定位，恢复时按快照重新插入。
+func (c *Copier) Restore(live, snapshot interface{}) error {
+	return c.copyIntoRoot(live, snapshot, true)
+}
+
+var (
+	emptyStructType  = reflect.TypeOf(struct{}{})
+	emptyStructValue = reflect.ValueOf(struct{}{})
+)
+
+// restoreMap 按 key 把 src 的条目写进 dst：已有 key 的 value 原地恢复，
+// 新 key 插入，dst 中多出的 key 删除
+func (tc *typeCopier) restoreMap(dst, src reflect.Value, st *copyState) {
+	keyType := tc.typ.Key()
+	mayNaN := keyMayNaN(keyType)
+	seen := reflect.MakeMapWithSize(reflect.MapOf(keyType, emptyStructType), src.Len())
+
+	var nanKeys, nanVals []reflect.Value
+	k, v := st.takeMapTemps(tc)
+	if tc.hooked {
+		defer traceKey(&k)
+	}
+	cell := reflect.New(tc.typ.Elem()).Elem()
+	// value 内的 map（含结构体字段、数组元素里的）、弱指针等会把 cell 内的地址登记进身份表，
+	// 复用 cell 会让后续条目覆盖已登记的内容，这类 value 每个条目需要独立的变量；
+	// 指针只登记其目标，可以复用
+	freshCell := tc.elem.hasRefs && tc.elem.kind != kindPtr
+	weakly := st.visited != nil && (tc.key.holdsWeak || tc.elem.holdsWeak)
+	var mark int32
+	var iter reflect.MapIter
+	iter.Reset(src)
+	for iter.Next() {
+		k.SetIterKey(&iter)
+		v.SetIterValue(&iter)
+		if weakly {
+			mark = st.weakMark()
+		}
+		newKey := tc.key.copy(k, st)
+		if mayNaN && !newKey.Equal(newKey) {
+			// k/v 是复用的临时变量，暂存前需要各自拷出一份
+			nk := reflect.New(keyType).Elem()
+			nk.Set(newKey)
+			nv := reflect.New(tc.typ.Elem()).Elem()
+			nv.Set(tc.elem.copy(v, st))
+			nanKeys = append(nanKeys, nk)
+			nanVals = append(nanVals, nv)
+			continue
+		}
+
+		if freshCell {
+			cell = reflect.New(tc.typ.Elem()).Elem()
+		}
+		if cur := dst.MapIndex(newKey); cur.IsValid() {
+			cell.Set(cur)
+		} else {
+			cell.SetZero()
+		}
+		tc.elem.copyInto(cell, v, st)
+		dst.SetMapIndex(newKey, cell)
+		if weakly && st.weakMark() != mark {
+			tc.deferWeakEntry(dst, newKey, cell, st)
+		}
+		seen.SetMapIndex(newKey, emptyStructValue)
+	}
+	st.putMapTemps(tc, k, v)
+
+	// seen 是 dst 的 key 子集，长度相同说明没有多余的 key
+	if dst.Len() > seen.Len() {
+		removeUnseen(dst, seen, mayNaN)
+	}
+	for i := range nanKeys {
+		dst.SetMapIndex(nanKeys[i], nanVals[i])
+	}
+}
+
+// removeUnseen 删除 dst 中不在 seen 里的 key。NaN key 无法按值删除，
+// 遇到时把保留的条目取出后清空重建
+func removeUnseen(dst, seen reflect.Value, mayNaN bool) {
+	var stale []reflect.Value
+	hasNaN := false
+	iter := dst.MapRange()
+	for iter.Next() {
+		lk := iter.Key()
+		if seen.MapIndex(lk).IsValid() {
+			continue
+		}
+		if mayNaN && !lk.Equal(lk) {
+			hasNaN = true
+			continue
+		}
+		stale = append(stale, lk)
+	}
+	for _, lk := range stale {
+		dst.SetMapIndex(lk, reflect.Value{})
+	}
+	if !hasNaN {
+		return
+	}
+
+	keys := make([]reflect.Value, 0, seen.Len())
+	vals := make([]reflect.Value, 0, seen.Len())
+	iter = dst.MapRange()
+	for iter.Next() {
+		if lk := iter.Key(); lk.Equal(lk) {
+			keys = append(keys, lk)
+			vals = append(vals, iter.Value())
+		}
+	}
+	dst.Clear()
+	for i := range keys {
+		dst.SetMapIndex(keys[i], vals[i])
+	}
+}
+
+// keyMayNaN 类型的值是否可能不等于自身（含浮点数或接口）
+func keyMayNaN(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128, reflect.Interface:
+		return true
+	case reflect.Array:
+		return keyMayNaN(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if keyMayNaN(t.Field(i).Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
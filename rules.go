@@ -0,0 +1,153 @@
+package deepcopy
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Rule 类型或字段的拷贝方式，用于控制无法加 tag 的第三方类型
+type Rule int
+
+const (
+	Deep    Rule = iota // 深拷贝（默认）；作为字段规则时表示取消该字段的规则
+	Shallow             // 按值浅拷贝：指针、切片、map 与原值共享
+	Skip                // 不拷贝：Copy/Clone 中为零值，CopyInto/Restore 保留 dst 原值
+	Zero                // 置零：CopyInto/Restore 也把 dst 清零
+)
+
+func (r Rule) String() string {
+	switch r {
+	case Deep:
+		return "Deep"
+	case Shallow:
+		return "Shallow"
+	case Skip:
+		return "Skip"
+	case Zero:
+		return "Zero"
+	}
+	return "Rule(" + strconv.Itoa(int(r)) + ")"
+}
+
+// typeRule 类型级规则，见 Shallow/Skip/Zero
+type typeRule struct {
+	t    reflect.Type
+	rule Rule
+}
+
+// fieldRule 字段级规则，见 SetFieldRule
+type fieldRule struct {
+	t     reflect.Type
+	field string
+	rule  Rule
+}
+
+// Shallow 这些类型的值在任何位置都按值浅拷贝
+func (c *Copier) Shallow(types ...reflect.Type) *Copier {
+	return c.addTypeRules("Shallow", Shallow, types)
+}
+
+// Skip 这些类型的值不拷贝：作为结构体字段时从计划中剔除，其余位置得到零值
+func (c *Copier) Skip(types ...reflect.Type) *Copier {
+	return c.addTypeRules("Skip", Skip, types)
+}
+
+// Zero 这些类型的值在任何位置都置为零值
+func (c *Copier) Zero(types ...reflect.Type) *Copier {
+	return c.addTypeRules("Zero", Zero, types)
+}
+
+func (c *Copier) addTypeRules(name string, rule Rule, types []reflect.Type) *Copier {
+	rules := slices.Clip(c.opts.typeRules)
+	for _, t := range types {
+		if t == nil {
+			panic("deepcopy: " + name + ": nil type")
+		}
+		rules = append(rules, typeRule{t, rule})
+	}
+	c.opts.typeRules = rules
+	c.rebind()
+	return c
+}
+
+// SetFieldRule 为 structType 的字段 field 指定拷贝方式，优先于字段类型上的规则。
+// structType 不是结构体或没有该字段时 panic
+func (c *Copier) SetFieldRule(structType reflect.Type, field string, rule Rule) *Copier {
+	if structType == nil || structType.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("deepcopy: SetFieldRule: %v is not a struct type", structType))
+	}
+	if f, ok := structType.FieldByName(field); !ok || len(f.Index) != 1 {
+		// 提升字段属于内嵌的结构体，应对该结构体类型设置
+		panic(fmt.Sprintf("deepcopy: SetFieldRule: %v has no direct field %q", structType, field))
+	}
+	if rule < Deep || rule > Zero {
+		panic(fmt.Sprintf("deepcopy: SetFieldRule: invalid %v", rule))
+	}
+	c.opts.fieldRules = append(slices.Clip(c.opts.fieldRules), fieldRule{structType, field, rule})
+	c.rebind()
+	return c
+}
+
+// typeRule 返回 t 的类型级规则，后设置的优先
+func (o *planOptions) typeRule(t reflect.Type) Rule {
+	for i := len(o.typeRules) - 1; i >= 0; i-- {
+		if o.typeRules[i].t == t {
+			return o.typeRules[i].rule
+		}
+	}
+	return Deep
+}
+
+// zeroes t 的类型级规则是否让拷贝结果为零值
+func (o *planOptions) zeroes(t reflect.Type) bool {
+	r := o.typeRule(t)
+	return r == Zero || r == Skip
+}
+
+// fieldRule 返回结构体 t 中字段 name 的规则，后设置的优先
+func (o *planOptions) fieldRule(t reflect.Type, name string) Rule {
+	for i := len(o.fieldRules) - 1; i >= 0; i-- {
+		if r := &o.fieldRules[i]; r.t == t && r.field == name {
+			return r.rule
+		}
+	}
+	return Deep
+}
+
+// ruleKind 规则对应的计划种类
+func ruleKind(r Rule) copierKind {
+	switch r {
+	case Shallow:
+		return kindShare
+	case Skip:
+		return kindSkip
+	default:
+		return kindZero
+	}
+}
+
+// ruleCopier 字段级规则使用的独立计划：不进缓存，同一类型的其他位置不受影响
+func ruleCopier(t reflect.Type, r Rule) *typeCopier {
+	tc := &typeCopier{typ: t, rtype: rtypeOf(t), kind: ruleKind(r)}
+	tc.flat = tc.kind == kindShare
+	return tc
+}
+
+// writeRules 规则按设置顺序编码，字段名带长度前缀
+func writeRules(sb *strings.Builder, types []typeRule, fields []fieldRule) {
+	if len(types) > 0 {
+		sb.WriteString("r")
+		for _, r := range types {
+			fmt.Fprintf(sb, ":%x=%d", uintptr(rtypeOf(r.t)), r.rule)
+		}
+	}
+	if len(fields) > 0 {
+		sb.WriteString("f")
+		for _, r := range fields {
+			fmt.Fprintf(sb, ":%x.%d:%s=%d", uintptr(rtypeOf(r.t)), len(r.field), r.field, r.rule)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package deepcopy
+
+import (
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+var (
+	lockerType  = reflect.TypeFor[sync.Locker]()
+	syncMapType = reflect.TypeFor[sync.Map]()
+	anyType     = reflect.TypeFor[any]()
+)
+
+// syncResetTypes 没有 Lock 方法、但内部状态同样不能照搬的同步原语：
+// 照搬会得到计数未归零的 WaitGroup、已执行过的 Once、带着等待队列的 Cond、
+// 与原对象共用 per-P 缓存的 Pool
+var syncResetTypes = map[reflect.Type]bool{
+	reflect.TypeFor[sync.Mutex]():     true,
+	reflect.TypeFor[sync.RWMutex]():   true,
+	reflect.TypeFor[sync.WaitGroup](): true,
+	reflect.TypeFor[sync.Once]():      true,
+	reflect.TypeFor[sync.Cond]():      true,
+	reflect.TypeFor[sync.Pool]():      true,
+}
+
+// isResetType 判断 t 是否为同步原语：拷贝结果中其未导出状态一律重置为零值，
+// 导出字段（如 sync.Cond.L、sync.Pool.New）照常拷贝。除内置列表外，
+// 带 noCopy 标记字段（零宽、*T 实现 Lock/Unlock，go vet copylocks 的约定）的结构体，
+// 以及 *T 自身实现了 Lock/Unlock 的结构体（自定义锁）同样视为同步原语；
+// 方法若来自某个字段（嵌入 sync.Mutex 等），则只重置该字段，结构体的其余字段照常拷贝。
+// sync/atomic 的类型化值虽带 noCopy 标记，但有专门的计划拷贝其值，不在此列。
+func isResetType(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	if syncResetTypes[t] {
+		return true
+	}
+	if atomicOpOf(t) != atomicNone {
+		return false
+	}
+	if hasNoCopyMarker(t) {
+		return true
+	}
+	if !reflect.PointerTo(t).Implements(lockerType) {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i).Type
+		if ft.Implements(lockerType) || reflect.PointerTo(ft).Implements(lockerType) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasNoCopyMarker t 是否有 noCopy 式的标记字段：零宽结构体，*T 实现 sync.Locker
+func hasNoCopyMarker(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i).Type
+		if ft.Kind() == reflect.Struct && ft.Size() == 0 && reflect.PointerTo(ft).Implements(lockerType) {
+			return true
+		}
+	}
+	return false
+}
+
+// syncMapOf 取 sync.Map 的指针；src 不可寻址时先落到临时变量上
+func syncMapOf(src reflect.Value) *sync.Map {
+	if !src.CanAddr() {
+		src = addressable(src)
+	}
+	return (*sync.Map)(unsafe.Pointer(src.UnsafeAddr()))
+}
+
+// copySyncMap 通过 Range/Store 深拷贝 sync.Map，key 和 value 按各自的动态类型深拷贝
+func (tc *typeCopier) copySyncMap(src reflect.Value, st *copyState) reflect.Value {
+	dst := reflect.New(tc.typ)
+	tc.fillSyncMap((*sync.Map)(dst.UnsafePointer()), syncMapOf(src), st)
+	return dst.Elem()
+}
+
+// copySyncMapInto 清空 dst 后重新填充，dst 本身保持原有身份
+func (tc *typeCopier) copySyncMapInto(dst, src reflect.Value, st *copyState) {
+	m := (*sync.Map)(unsafe.Pointer(dst.UnsafeAddr()))
+	m.Clear()
+	tc.fillSyncMap(m, syncMapOf(src), st)
+}
+
+func (tc *typeCopier) fillSyncMap(dst, src *sync.Map, st *copyState) {
+	src.Range(func(k, v any) bool {
+		dst.Store(tc.copyAny(k, st), tc.copyAny(v, st))
+		return true
+	})
+}
+
+// copyAny 用 any 的计划（tc.elem）深拷贝一个接口值，nil 原样返回
+func (tc *typeCopier) copyAny(x any, st *copyState) any {
+	return tc.elem.copy(reflect.ValueOf(&x).Elem(), st).Interface()
+}
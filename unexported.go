@@ -0,0 +1,116 @@
+package deepcopy
+
+import (
+	"fmt"
+	"path"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// AllowUnexported 为匹配的包开启未导出字段拷贝，其余包仍按 SetCopyUnexported 的设置处理。
+// 模式按结构体所在包的导入路径匹配：
+//   - path.Match 通配符，如 "example.com/app/*"（* 不跨越 /）；
+//   - 以 "/..." 结尾匹配该包及其所有子包，如 "example.com/app/..."；
+//   - "std" 匹配标准库：导入路径首段不含 "."，且不是 main 包、
+//     go run 的 command-line-arguments，也不属于构建信息中首段不含 "." 的本地模块
+//     （如 module myapp 的 myapp/...）。
+//
+// 模式写错时立即 panic，与 regexp.MustCompile 一样在配置阶段暴露问题。
+func (c *Copier) AllowUnexported(patterns ...string) *Copier {
+	checkPkgPatterns("AllowUnexported", patterns)
+	c.opts.allowUnexported = append(slices.Clip(c.opts.allowUnexported), patterns...)
+	c.rebind()
+	return c
+}
+
+// DenyUnexported 匹配的包永不拷贝未导出字段，优先级高于 AllowUnexported 和 SetCopyUnexported。
+// 被拒绝的字段置零；严格模式下返回错误。模式语法同 AllowUnexported。
+func (c *Copier) DenyUnexported(patterns ...string) *Copier {
+	checkPkgPatterns("DenyUnexported", patterns)
+	c.opts.denyUnexported = append(slices.Clip(c.opts.denyUnexported), patterns...)
+	c.rebind()
+	return c
+}
+
+func checkPkgPatterns(fn string, patterns []string) {
+	for _, p := range patterns {
+		if _, err := path.Match(strings.TrimSuffix(p, "/..."), ""); err != nil {
+			panic(fmt.Sprintf("deepcopy: %s: bad pattern %q: %v", fn, p, err))
+		}
+	}
+}
+
+// unexportedPolicy 判断 pkg 中结构体的未导出字段是否拷贝；denied 表示被 DenyUnexported 显式拒绝
+func (o *planOptions) unexportedPolicy(pkg string) (ok, denied bool) {
+	if matchAnyPkg(o.denyUnexported, pkg) {
+		return false, true
+	}
+	return o.copyUnexported || matchAnyPkg(o.allowUnexported, pkg), false
+}
+
+func matchAnyPkg(patterns []string, pkg string) bool {
+	for _, p := range patterns {
+		if matchPkg(p, pkg) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPkg(pattern, pkg string) bool {
+	switch {
+	case pattern == "std":
+		return isStdPkg(pkg, localModules())
+	case strings.HasSuffix(pattern, "/..."):
+		prefix := strings.TrimSuffix(pattern, "/...")
+		if ok, _ := path.Match(prefix, pkg); ok {
+			return true
+		}
+		// 前缀本身也允许通配符：逐级截短 pkg 与前缀比较
+		for i := strings.LastIndexByte(pkg, '/'); i > 0; i = strings.LastIndexByte(pkg[:i], '/') {
+			if ok, _ := path.Match(prefix, pkg[:i]); ok {
+				return true
+			}
+		}
+		return false
+	default:
+		ok, _ := path.Match(pattern, pkg)
+		return ok
+	}
+}
+
+// isStdPkg 按 go 工具的约定判断标准库：首段不含 "."；
+// 排除 main 包、command-line-arguments 以及 modules 中的本地模块
+func isStdPkg(pkg string, modules []string) bool {
+	first, _, _ := strings.Cut(pkg, "/")
+	if strings.Contains(first, ".") || pkg == "main" || pkg == "command-line-arguments" {
+		return false
+	}
+	for _, m := range modules {
+		if pkg == m || strings.HasPrefix(pkg, m+"/") {
+			return false
+		}
+	}
+	return true
+}
+
+// localModules 构建信息中模块路径首段不含 "." 的模块（主模块与依赖），只读取一次
+var localModules = sync.OnceValue(func() []string {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+	var mods []string
+	add := func(m string) {
+		if first, _, _ := strings.Cut(m, "/"); m != "" && !strings.Contains(first, ".") {
+			mods = append(mods, m)
+		}
+	}
+	add(bi.Main.Path)
+	for _, d := range bi.Deps {
+		add(d.Path)
+	}
+	return mods
+})
@@ -0,0 +1,312 @@
+package deepcopy
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// copyState 单次 Copy/Clone 的上下文，沿递归向下传递
+type copyState struct {
+	plans      *planSet      // 本次拷贝使用的计划缓存（接口动态类型在此查找）
+	visited    *visitTable   // nil 表示本次拷贝无需身份跟踪
+	par        *parallelPool // nil 表示顺序拷贝
+	alloc      Allocator     // nil 表示使用 reflect.New
+	owned      *visitTable   // CopyInto：已被复用的 dst 目标，nil 表示不检查 dst 内部共享
+	restore    bool          // Restore：map 按 key 对齐写入，保留现有 value 的身份
+	weakPolicy WeakPolicy    // weak.Pointer 目标未被拷贝时的处理方式
+	locks      *lockQueue    // 锁感知模式：持锁期间发现的可加锁对象，nil 表示未开启
+	held       bool          // 锁感知模式：当前是否持有某个对象的锁
+
+	// CopyInto/Restore 最近一次写入已有 map 时用完的 key/value 临时变量，
+	// 同类型的下一个 map 直接复用；并行 worker 各自从空缓存开始
+	tmpMap     *typeCopier
+	tmpK, tmpV reflect.Value
+}
+
+// takeMapTemps 取出 tc 的 key/value 临时变量；使用期间从缓存中移走，
+// 同类型 map 嵌套填充时内层会另行分配，不会覆盖外层正在使用的变量
+func (st *copyState) takeMapTemps(tc *typeCopier) (k, v reflect.Value) {
+	if st.tmpMap == tc {
+		st.tmpMap = nil
+		return st.tmpK, st.tmpV
+	}
+	return reflect.New(tc.typ.Key()).Elem(), reflect.New(tc.typ.Elem()).Elem()
+}
+
+func (st *copyState) putMapTemps(tc *typeCopier, k, v reflect.Value) {
+	st.tmpMap, st.tmpK, st.tmpV = tc, k, v
+}
+
+// visitSlot 身份表槽位，24 字节：
+// src 为 0 表示空槽；typ 是目标类型的 *rtype，用于区分同地址不同类型
+// （如结构体与其首字段）；dst 持有目标对象指针，保持其对 GC 可达。
+type visitSlot struct {
+	src uintptr
+	typ unsafe.Pointer
+	dst unsafe.Pointer
+}
+
+// visitTable 专用的开放寻址身份表（线性探测，2 的幂容量）。
+// 相比 map[visitKey]reflect.Value：key 不含接口，哈希只需一次乘法；
+// 值只存一个指针而不是 24 字节的 reflect.Value；容量任意大也可复用。
+type visitTable struct {
+	slots  []visitSlot
+	count  int
+	shift  uint         // 64 - log2(len(slots))，Fibonacci 哈希取高位
+	shared bool         // 并行拷贝时多个 worker 共用，查找+登记需加锁
+	mu     sync.Mutex   // 仅 shared 时使用
+	weak   []weakFixup  // 目标尚未拷贝的弱指针，拷贝结束时统一回填
+	weakN  atomic.Int32 // len(weak)，供不持锁的 weakMark 读取
+
+	weakEntries []weakEntry // 值内含待回填弱指针的 map 条目，回填后重新写入
+
+	// 大表专用：used 记录本次拷贝占用的槽位下标，归还时只清这些槽位；
+	// 超过 cap(used) 后置 logged = false，此时登记项已占容量的 1/8 以上，整表清零的代价与用量相当
+	used   []uint32
+	logged bool
+}
+
+const (
+	visitTableMinSize = 16
+	// visitTableLargeSize 超过该容量的槽位数组单独放进 largeSlotsPool：
+	// 小拷贝从 visitTablePool 取到的总是小表，大拷贝扩容越过该容量时直接换上池中的大数组
+	visitTableLargeSize = 1 << 14
+)
+
+// visitTablePool 复用身份表；池中对象在 GC 时自然回收，不会无限驻留
+var visitTablePool = sync.Pool{
+	New: func() interface{} {
+		t := &visitTable{}
+		t.init(visitTableMinSize)
+		return t
+	},
+}
+
+// largeSlots 池中的大槽位数组，已清零
+type largeSlots struct {
+	slots []visitSlot
+	used  []uint32
+}
+
+// largeSlotsPool 复用任意大小的大槽位数组，一次百万级的拷贝之后，
+// 下一次同等规模的拷贝不必从小表开始逐级扩容
+var largeSlotsPool sync.Pool
+
+func acquireVisitTable() *visitTable {
+	return visitTablePool.Get().(*visitTable)
+}
+
+// releaseVisitTable 清空后放回池中，清空的代价与本次实际用量相当：
+// 大数组只清本次占用的槽位后归还 largeSlotsPool；小表登记项远少于容量时换一张合适大小的表
+func releaseVisitTable(t *visitTable) {
+	if len(t.slots) > visitTableLargeSize {
+		largeSlotsPool.Put(t.detachLarge())
+		t.init(visitTableMinSize)
+	} else {
+		switch {
+		case len(t.slots) > visitTableMinSize && t.count*8 < len(t.slots):
+			t.init(visitTableSizeFor(t.count))
+		case t.count > 0:
+			clear(t.slots) // 清掉 dst 指针，避免池中对象拖住上次拷贝的结果
+			t.count = 0
+		}
+	}
+	t.shared = false
+	clear(t.weak)
+	t.weak = t.weak[:0]
+	t.weakN.Store(0)
+	clear(t.weakEntries)
+	t.weakEntries = t.weakEntries[:0]
+	visitTablePool.Put(t)
+}
+
+// detachLarge 清空并取下大槽位数组
+func (t *visitTable) detachLarge() *largeSlots {
+	if t.logged {
+		for _, i := range t.used {
+			t.slots[i] = visitSlot{}
+		}
+	} else {
+		clear(t.slots)
+	}
+	ls := &largeSlots{slots: t.slots, used: t.used[:0]}
+	t.slots, t.used, t.logged = nil, nil, false
+	return ls
+}
+
+// visitTableSizeFor 容纳 n 个登记项且不触发扩容的最小容量
+func visitTableSizeFor(n int) int {
+	size := visitTableMinSize
+	for (n+1)*4 > size*3 {
+		size *= 2
+	}
+	return size
+}
+
+// lock/unlock 顺序拷贝时是空操作，不给单线程路径增加开销
+func (t *visitTable) lock() {
+	if t.shared {
+		t.mu.Lock()
+	}
+}
+
+func (t *visitTable) unlock() {
+	if t.shared {
+		t.mu.Unlock()
+	}
+}
+
+func (t *visitTable) init(size int) {
+	t.slots = make([]visitSlot, size)
+	t.shift = uint(64 - bitsLen(uint64(size-1)))
+	t.count = 0
+}
+
+func bitsLen(x uint64) int {
+	n := 0
+	for ; x != 0; x >>= 1 {
+		n++
+	}
+	return n
+}
+
+func (t *visitTable) index(src uintptr, typ unsafe.Pointer) uintptr {
+	h := uint64(src) ^ uint64(uintptr(typ))>>3
+	return uintptr((h * 0x9E3779B97F4A7C15) >> t.shift)
+}
+
+// lookup 查找 (src, typ) 对应的目标对象
+func (t *visitTable) lookup(src uintptr, typ unsafe.Pointer) (unsafe.Pointer, bool) {
+	if t.count == 0 {
+		return nil, false
+	}
+	mask := uintptr(len(t.slots) - 1)
+	for i := t.index(src, typ); ; i = (i + 1) & mask {
+		s := &t.slots[i]
+		if s.src == 0 {
+			return nil, false
+		}
+		if s.src == src && s.typ == typ {
+			return s.dst, true
+		}
+	}
+}
+
+// insert 登记 (src, typ) -> dst；调用方保证 key 尚不存在
+func (t *visitTable) insert(src uintptr, typ, dst unsafe.Pointer) {
+	if (t.count+1)*4 > len(t.slots)*3 { // 负载因子 0.75
+		t.grow()
+	}
+	t.put(src, typ, dst)
+	t.count++
+}
+
+// replace 把已登记的 (src, typ) 改为指向 dst，key 不存在时不做任何事
+func (t *visitTable) replace(src uintptr, typ, dst unsafe.Pointer) {
+	if t.count == 0 {
+		return
+	}
+	mask := uintptr(len(t.slots) - 1)
+	for i := t.index(src, typ); t.slots[i].src != 0; i = (i + 1) & mask {
+		if s := &t.slots[i]; s.src == src && s.typ == typ {
+			s.dst = dst
+			return
+		}
+	}
+}
+
+func (t *visitTable) put(src uintptr, typ, dst unsafe.Pointer) {
+	mask := uintptr(len(t.slots) - 1)
+	i := t.index(src, typ)
+	for t.slots[i].src != 0 {
+		i = (i + 1) & mask
+	}
+	t.slots[i] = visitSlot{src: src, typ: typ, dst: dst}
+	if t.logged {
+		if len(t.used) == cap(t.used) {
+			t.logged = false
+		} else {
+			t.used = append(t.used, uint32(i))
+		}
+	}
+}
+
+func (t *visitTable) grow() {
+	old := t.slots
+	count := t.count
+	size := len(old) * 2
+	if size > visitTableLargeSize {
+		t.growLarge(size)
+	} else {
+		t.init(size)
+	}
+	for i := range old {
+		if old[i].src != 0 {
+			t.put(old[i].src, old[i].typ, old[i].dst)
+		}
+	}
+	t.count = count
+	if len(old) > visitTableLargeSize {
+		// 被换下的大数组同样归还；它已被填满 3/4，整表清零的代价与用量相当
+		clear(old)
+		largeSlotsPool.Put(&largeSlots{slots: old})
+	}
+}
+
+// growLarge 换上至少 size 个槽位的大数组：优先取池中的，容量不足时放回并新建
+func (t *visitTable) growLarge(size int) {
+	ls, _ := largeSlotsPool.Get().(*largeSlots)
+	if ls == nil || len(ls.slots) < size {
+		if ls != nil {
+			largeSlotsPool.Put(ls)
+		}
+		ls = &largeSlots{slots: make([]visitSlot, size)}
+	}
+	t.slots = ls.slots
+	t.shift = uint(64 - bitsLen(uint64(len(ls.slots)-1)))
+	t.count = 0
+	t.used = ls.used[:0]
+	if cap(t.used) == 0 {
+		t.used = make([]uint32, 0, len(ls.slots)/8)
+	}
+	t.logged = true
+}
+
+// rtypeOf 取 reflect.Type 接口的数据字（*rtype），每个类型唯一
+func rtypeOf(t reflect.Type) unsafe.Pointer {
+	return (*[2]unsafe.Pointer)(unsafe.Pointer(&t))[1]
+}
+
+// claimPtr 查找或登记指针拷贝结果，返回 *T 类型的 Value。
+// fresh 为 true 表示目标刚由本次调用分配，调用方负责填充其内容；
+// 查找与登记在同一临界区内完成，并行时同一对象只会被一个 worker 拷贝。
+func (st *copyState) claimPtr(src reflect.Value, tc *typeCopier) (dst reflect.Value, fresh bool) {
+	t := st.visited
+	key := src.Pointer()
+	t.lock()
+	defer t.unlock()
+	if p, ok := t.lookup(key, tc.rtype); ok {
+		return reflect.NewAt(tc.typ.Elem(), p), false
+	}
+	dst = st.newElem(tc)
+	t.insert(key, tc.rtype, dst.UnsafePointer())
+	return dst, true
+}
+
+// claimMap 查找或登记 map 拷贝结果，mk 创建目标 map。map 值本身不可取址，
+// 因此放进一个堆上的变量里，登记该变量的地址，返回指向它的可寻址 Value。
+func (st *copyState) claimMap(src reflect.Value, tc *typeCopier, mk func() reflect.Value) (dst reflect.Value, fresh bool) {
+	t := st.visited
+	key := src.Pointer()
+	t.lock()
+	defer t.unlock()
+	if p, ok := t.lookup(key, tc.rtype); ok {
+		return reflect.NewAt(tc.typ, p).Elem(), false
+	}
+	cell := reflect.New(tc.typ)
+	cell.Elem().Set(mk())
+	t.insert(key, tc.rtype, cell.UnsafePointer())
+	return cell.Elem(), true
+}
@@ -0,0 +1,195 @@
+package deepcopy
+
+import (
+	"reflect"
+	"strings"
+	"unsafe"
+	"weak"
+)
+
+// WeakPolicy 决定 weak.Pointer 的目标不在拷贝结果中时如何处理。
+// 目标在本次拷贝中被（强引用）拷贝过时，弱指针总是改指向它的拷贝。
+type WeakPolicy int
+
+const (
+	WeakKeep  WeakPolicy = iota // 继续指向原对象（默认）
+	WeakClear                   // 置为 nil
+)
+
+// SetWeakPolicy 设置 weak.Pointer 目标未被拷贝时的处理方式
+func (c *Copier) SetWeakPolicy(p WeakPolicy) *Copier {
+	c.weakPolicy = p
+	return c
+}
+
+// weakLayout weak.Pointer[T] 的内部布局镜像：u 是运行时分配的弱句柄，与 T 无关
+type weakLayout struct {
+	_ [0]*byte
+	u unsafe.Pointer
+}
+
+// weakFixup 拷贝时尚未见到目标的弱指针，整次拷贝结束后再到身份表中查一次
+type weakFixup struct {
+	slot *unsafe.Pointer // dst 中弱指针的 u
+	src  unsafe.Pointer  // 原目标，登记期间保持其可达
+	typ  unsafe.Pointer  // 目标指针类型 *T 的 *rtype
+}
+
+// weakPlanOf 为 weak.Pointer[T] 生成专用计划：按 *T 在身份表中查找目标的拷贝
+func weakPlanOf(t reflect.Type) *builtinPlan {
+	if t.Kind() != reflect.Struct || t.PkgPath() != "weak" || !strings.HasPrefix(t.Name(), "Pointer[") ||
+		!layoutMatches(t, reflect.TypeFor[weakLayout]()) {
+		return nil
+	}
+	key := rtypeOf(t.Field(0).Type.Elem())
+	return &builtinPlan{
+		fill: func(_ *typeCopier, d, s unsafe.Pointer, _ bool, st *copyState) {
+			fillWeak(key, (*weakLayout)(d), (*weakLayout)(s), st)
+		},
+		identity: true,
+		weak:     true,
+	}
+}
+
+func fillWeak(key unsafe.Pointer, dst, src *weakLayout, st *copyState) {
+	target := weakTarget(src.u)
+	if target == nil {
+		dst.u = nil // 从未指向对象，或目标已被回收
+		return
+	}
+	if t := st.visited; t != nil {
+		t.lock()
+		p, ok := t.lookup(uintptr(target), key)
+		if !ok {
+			t.weak = append(t.weak, weakFixup{slot: &dst.u, src: target, typ: key})
+			t.weakN.Store(int32(len(t.weak)))
+		}
+		t.unlock()
+		if ok {
+			dst.u = weakHandle(p)
+			return
+		}
+	}
+	if st.weakPolicy == WeakClear {
+		dst.u = nil
+	} else {
+		dst.u = src.u
+	}
+}
+
+// resolveWeak 拷贝结束后处理先于目标被拷贝的弱指针，
+// 随后把 value（或 key）内含这些弱指针的 map 条目重新写入
+func (st *copyState) resolveWeak() {
+	t := st.visited
+	if t == nil || len(t.weak) == 0 {
+		return
+	}
+	for _, f := range t.weak {
+		if p, ok := t.lookup(uintptr(f.src), f.typ); ok {
+			*f.slot = weakHandle(p)
+		}
+	}
+	for _, e := range t.weakEntries {
+		if e.old.IsValid() {
+			e.m.SetMapIndex(e.old, reflect.Value{}) // key 本身被回填，按写入时的 key 删除旧条目
+		}
+		e.m.SetMapIndex(e.k, e.v)
+	}
+}
+
+// weakEntry 写入 map 时内含待回填弱指针的条目。SetMapIndex 存的是副本，
+// 回填只改到 k/v 这两个拷贝结果，需要在回填后重新写入 m
+type weakEntry struct {
+	m, k, v reflect.Value
+	old     reflect.Value // key 内含弱指针时写入 m 的原 key，否则无效
+}
+
+// analyzeWeak 标记按值可能含有待回填弱指针的计划：weak.Pointer 本身、
+// 接口（动态类型为指针形状时弱指针就在数据字里，见 boxWeak），以及按值含有它们的结构体与数组
+func analyzeWeak(order []*typeCopier) {
+	for _, tc := range order {
+		tc.holdsWeak = tc.kind == kindBuiltin && tc.bp.weak || tc.kind == kindInterface
+	}
+	propagateByValue(order, func(tc *typeCopier) *bool { return &tc.holdsWeak })
+}
+
+// weakMark 当前已登记的待回填弱指针数，前后两次不同说明期间拷贝的值里有弱指针待回填
+func (st *copyState) weakMark() int32 {
+	if t := st.visited; t != nil {
+		return t.weakN.Load()
+	}
+	return 0
+}
+
+// deferWeakEntry 登记刚写入 m 的条目，回填后重新写入。newKey/newVal 是拷贝结果：
+// 不含弱指针的一方可能是调用方复用的临时变量，先拷出一份
+func (tc *typeCopier) deferWeakEntry(m, newKey, newVal reflect.Value, st *copyState) {
+	e := weakEntry{m: m, k: newKey, v: newVal}
+	if tc.key.holdsWeak {
+		e.old = detach(newKey)
+	} else {
+		e.k = detach(newKey)
+	}
+	if !tc.elem.holdsWeak {
+		e.v = detach(newVal)
+	}
+	t := st.visited
+	t.lock()
+	t.weakEntries = append(t.weakEntries, e)
+	t.unlock()
+}
+
+func detach(v reflect.Value) reflect.Value {
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	return c
+}
+
+// boxWeak 把 copyDynamic 的结果 copied 写进可寻址的接口变量 dst，
+// 拷贝 copied 期间登记过待回填的弱指针（mark 之后）时使用：接口不能只持有装箱时的副本。
+// 按值装箱的类型让数据字直接指向 copied；指针形状的类型值就在数据字里，
+// 把指向 copied 的待回填槽位改指向 dst 的数据字
+func boxWeak(dst, copied reflect.Value, mark int32, st *copyState) {
+	dst.Set(copied)
+	word := &(*[2]unsafe.Pointer)(unsafe.Pointer(dst.UnsafeAddr()))[1]
+	at := unsafe.Pointer(copied.UnsafeAddr())
+	if directIface(copied.Type()) {
+		st.visited.retargetWeak(mark, (*unsafe.Pointer)(at), word)
+	} else {
+		*word = at // copied 是本次拷贝新分配的变量，此后不会再被改写
+	}
+}
+
+// retargetWeak 把 from 之后登记的、槽位为 old 的待回填弱指针改写到 slot
+func (t *visitTable) retargetWeak(from int32, old, slot *unsafe.Pointer) {
+	t.lock()
+	for i := range t.weak[from:] {
+		if f := &t.weak[int(from)+i]; f.slot == old {
+			f.slot = slot
+		}
+	}
+	t.unlock()
+}
+
+// directIface 类型的值是否直接存放在接口的数据字里（指针形状，不另外装箱）。
+// 判定规则随编译器版本变化（如是否忽略零长字段），这里用零值实测：装箱时数据字总是非 nil
+func directIface(t reflect.Type) bool {
+	z := reflect.Zero(t).Interface()
+	return (*[2]unsafe.Pointer)(unsafe.Pointer(&z))[1] == nil
+}
+
+// weakHandle 取得 p 的弱句柄。句柄只与对象地址有关，借 weak.Pointer[byte] 申请即可
+func weakHandle(p unsafe.Pointer) unsafe.Pointer {
+	w := weak.Make((*byte)(p))
+	return (*weakLayout)(unsafe.Pointer(&w)).u
+}
+
+// weakTarget 由弱句柄取回强指针，目标已被回收时返回 nil
+func weakTarget(u unsafe.Pointer) unsafe.Pointer {
+	if u == nil {
+		return nil
+	}
+	var w weak.Pointer[byte]
+	(*weakLayout)(unsafe.Pointer(&w)).u = u
+	return unsafe.Pointer(w.Value())
+}